@@ -13,6 +13,10 @@ type ExprVisitor interface {
 	VisitLiteralExpr(expr Literal) (interface{}, LoxError)
 	VisitVariableExpr(expr Variable) (interface{}, LoxError)
 	VisitAssignExpr(expr Assign) (interface{}, LoxError)
+	VisitConditionalExpr(expr Conditional) (interface{}, LoxError)
+	VisitInterpolationExpr(expr Interpolation) (interface{}, LoxError)
+	VisitCompoundAssignExpr(expr CompoundAssign) (interface{}, LoxError)
+	VisitIncrementExpr(expr Increment) (interface{}, LoxError)
 	VisitLogicalExpr(expr Logical) (interface{}, LoxError)
 	VisitCallExpr(expr Call) (interface{}, LoxError)
 }
@@ -56,8 +60,14 @@ func (l Literal) Accept(visitor ExprVisitor) (interface{}, LoxError) {
 	return visitor.VisitLiteralExpr(l)
 }
 
+// ExprID identifies a variable reference among every one a parser has read,
+// so the interpreter can tell apart references to the same name in the same
+// place in different REPL inputs or scripts
+type ExprID int64
+
 type Variable struct {
 	Name Token
+	ID   ExprID
 }
 
 func (v Variable) Accept(visitor ExprVisitor) (interface{}, LoxError) {
@@ -67,12 +77,38 @@ func (v Variable) Accept(visitor ExprVisitor) (interface{}, LoxError) {
 type Assign struct {
 	Name  Token
 	Value Expr
+	ID    ExprID
 }
 
 func (a Assign) Accept(visitor ExprVisitor) (interface{}, LoxError) {
 	return visitor.VisitAssignExpr(a)
 }
 
+// CompoundAssign combines a variable's value with another, as in a += 1
+type CompoundAssign struct {
+	Name     Token
+	Operator Token
+	Value    Expr
+	ID       ExprID
+}
+
+func (c CompoundAssign) Accept(visitor ExprVisitor) (interface{}, LoxError) {
+	return visitor.VisitCompoundAssignExpr(c)
+}
+
+// Increment adds or subtracts one from a variable with ++ or --, evaluating
+// to the new value if it's a prefix and the old value otherwise
+type Increment struct {
+	Name     Token
+	Operator Token
+	Prefix   bool
+	ID       ExprID
+}
+
+func (i Increment) Accept(visitor ExprVisitor) (interface{}, LoxError) {
+	return visitor.VisitIncrementExpr(i)
+}
+
 type Logical struct {
 	Left     Expr
 	Operator Token
@@ -83,6 +119,27 @@ func (l Logical) Accept(visitor ExprVisitor) (interface{}, LoxError) {
 	return visitor.VisitLogicalExpr(l)
 }
 
+// Conditional is cond ? then : else, evaluating only the branch it picks
+type Conditional struct {
+	Condition Expr
+	Then      Expr
+	Else      Expr
+}
+
+func (c Conditional) Accept(visitor ExprVisitor) (interface{}, LoxError) {
+	return visitor.VisitConditionalExpr(c)
+}
+
+// Interpolation is a string with ${} holes, joining the text of each part as
+// print would show it
+type Interpolation struct {
+	Parts []Expr
+}
+
+func (i Interpolation) Accept(visitor ExprVisitor) (interface{}, LoxError) {
+	return visitor.VisitInterpolationExpr(i)
+}
+
 type Call struct {
 	Callee    Expr
 	Paren     Token
@@ -129,6 +186,7 @@ func (p Print) Accept(visitor StmtVisitor) LoxError {
 type Var struct {
 	Name        Token
 	Initialiser *Expr
+	Doc         string // the /// comments before the declaration
 }
 
 func (v Var) Accept(visitor StmtVisitor) LoxError {
@@ -166,6 +224,7 @@ type Function struct {
 	Name   Token
 	Params []Token
 	Body   Block
+	Doc    string // the /// comments before the declaration
 }
 
 func (f Function) Accept(visitor StmtVisitor) LoxError {
@@ -0,0 +1,614 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// The JSON form of a syntax tree is an array of statement nodes. Every node
+// is an object with a "kind" naming its AST type and one field per struct
+// field, e.g.
+//
+//	{"kind": "Print", "expression": {"kind": "Literal", "value": 1}}
+//
+// Tokens keep their type, lexeme, literal and position. Literal floats are
+// always written with a decimal point or exponent, so they can be told apart
+// from integers when read back, while big integers and decimals are written
+// as strings in objects, {"bigint": "1"} and {"decimal": "1.50"}. The IDs the
+// parser gives variable references aren't written, but given out afresh when
+// a tree is read.
+
+func MarshalAST(stmts []Stmt) ([]byte, error) {
+	nodes := make([]interface{}, len(stmts))
+	for i, stmt := range stmts {
+		node, err := encodeStmt(stmt)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = node
+	}
+	return json.MarshalIndent(nodes, "", "  ")
+}
+
+// UnmarshalAST reads a syntax tree written by MarshalAST, with parser giving
+// out the IDs of variable references as it does when parsing
+func UnmarshalAST(data []byte, parser *Parser) ([]Stmt, error) {
+	var nodes []json.RawMessage
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return nil, err
+	}
+	return astDecoder{parser}.decodeStmts(nodes)
+}
+
+type jsonNode map[string]interface{}
+
+type jsonToken struct {
+	Type    Lexeme      `json:"type"`
+	Lexeme  string      `json:"lexeme"`
+	Literal jsonLiteral `json:"literal"`
+	Line    int         `json:"line"`
+	Column  int         `json:"column"`
+}
+
+func encodeToken(token Token) jsonToken {
+	return jsonToken{token.tokenType, token.lexeme, jsonLiteral{token.literal}, token.line, token.column}
+}
+
+func (t jsonToken) token() Token {
+	return Token{
+		tokenType: t.Type,
+		lexeme:    t.Lexeme,
+		literal:   t.Literal.value,
+		line:      t.Line,
+		column:    t.Column,
+	}
+}
+
+// jsonLiteral is a literal value that keeps integers and floats distinct
+type jsonLiteral struct {
+	value interface{}
+}
+
+func (l jsonLiteral) MarshalJSON() ([]byte, error) {
+	switch value := l.value.(type) {
+	case *big.Int:
+		return json.Marshal(map[string]string{"bigint": value.String()})
+	case *Decimal:
+		return json.Marshal(map[string]string{"decimal": value.String()})
+	}
+
+	f, ok := l.value.(float64)
+	if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+		return json.Marshal(l.value)
+	}
+
+	text := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".e") {
+		text += ".0"
+	}
+	return []byte(text), nil
+}
+
+func (l *jsonLiteral) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&l.value); err != nil {
+		return err
+	}
+
+	if object, ok := l.value.(map[string]interface{}); ok {
+		return l.unmarshalObject(object)
+	}
+	number, ok := l.value.(json.Number)
+	if !ok {
+		return nil
+	}
+	if !strings.ContainsAny(string(number), ".eE") {
+		integer, err := number.Int64()
+		l.value = integer
+		return err
+	}
+	float, err := number.Float64()
+	l.value = float
+	return err
+}
+
+func (l *jsonLiteral) unmarshalObject(object map[string]interface{}) error {
+	if text, ok := object["bigint"].(string); ok && len(object) == 1 {
+		value, ok := new(big.Int).SetString(text, 10)
+		if !ok {
+			return fmt.Errorf("invalid bigint %q", text)
+		}
+		l.value = value
+		return nil
+	}
+	if text, ok := object["decimal"].(string); ok && len(object) == 1 {
+		value, err := parseDecimal(text)
+		if err != nil {
+			return fmt.Errorf("invalid decimal %q: %w", text, err)
+		}
+		l.value = value
+		return nil
+	}
+	return fmt.Errorf("unknown literal %v", object)
+}
+
+func encodeTokens(tokens []Token) []jsonToken {
+	encoded := make([]jsonToken, len(tokens))
+	for i, token := range tokens {
+		encoded[i] = encodeToken(token)
+	}
+	return encoded
+}
+
+// MarshalTokens encodes a scanner's output, along with any errors it hit
+func MarshalTokens(tokens []Token, errs []LoxError) ([]byte, error) {
+	type jsonError struct {
+		Message string `json:"message"`
+		Line    int    `json:"line"`
+		Column  int    `json:"column"`
+	}
+
+	encodedErrors := make([]jsonError, len(errs))
+	for i, err := range errs {
+		encodedErrors[i] = jsonError{err.Error(), err.Token().line, err.Token().column}
+	}
+
+	return json.MarshalIndent(struct {
+		Tokens []jsonToken `json:"tokens"`
+		Errors []jsonError `json:"errors"`
+	}{encodeTokens(tokens), encodedErrors}, "", "  ")
+}
+
+// Encoding
+
+func encodeStmt(stmt Stmt) (jsonNode, error) {
+	switch s := stmt.(type) {
+	case Expression:
+		expr, err := encodeExpr(s.Expression)
+		return jsonNode{"kind": "Expression", "expression": expr}, err
+	case Print:
+		expr, err := encodeExpr(s.Expression)
+		return jsonNode{"kind": "Print", "expression": expr}, err
+	case Var:
+		init, err := encodeOptionalExpr(s.Initialiser)
+		return jsonNode{"kind": "Var", "name": encodeToken(s.Name), "initialiser": init, "doc": s.Doc}, err
+	case Block:
+		stmts, err := encodeStmts(s.Statements)
+		return jsonNode{"kind": "Block", "statements": stmts}, err
+	case If:
+		condition, err := encodeExpr(s.Condition)
+		if err != nil {
+			return nil, err
+		}
+		then, err := encodeStmt(s.Then)
+		if err != nil {
+			return nil, err
+		}
+		var elseBranch jsonNode
+		if s.Else != nil {
+			elseBranch, err = encodeStmt(s.Else)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return jsonNode{"kind": "If", "condition": condition, "then": then, "else": elseBranch}, nil
+	case While:
+		condition, err := encodeExpr(s.Condition)
+		if err != nil {
+			return nil, err
+		}
+		body, err := encodeStmt(s.Body)
+		return jsonNode{"kind": "While", "condition": condition, "body": body}, err
+	case Function:
+		body, err := encodeStmt(s.Body)
+		return jsonNode{"kind": "Function", "name": encodeToken(s.Name), "params": encodeTokens(s.Params), "body": body, "doc": s.Doc}, err
+	case Return:
+		value, err := encodeOptionalExpr(s.Value)
+		return jsonNode{"kind": "Return", "keyword": encodeToken(s.Keyword), "value": value}, err
+	}
+
+	return nil, fmt.Errorf("cannot encode statement of type %T", stmt)
+}
+
+func encodeStmts(stmts []Stmt) ([]jsonNode, error) {
+	nodes := make([]jsonNode, len(stmts))
+	for i, stmt := range stmts {
+		node, err := encodeStmt(stmt)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
+
+func encodeExpr(expr Expr) (jsonNode, error) {
+	switch e := expr.(type) {
+	case Binary:
+		left, right, err := encodeOperands(e.Left, e.Right)
+		return jsonNode{"kind": "Binary", "left": left, "operator": encodeToken(e.Operator), "right": right}, err
+	case Logical:
+		left, right, err := encodeOperands(e.Left, e.Right)
+		return jsonNode{"kind": "Logical", "left": left, "operator": encodeToken(e.Operator), "right": right}, err
+	case Conditional:
+		condition, err := encodeExpr(e.Condition)
+		if err != nil {
+			return nil, err
+		}
+		then, elseBranch, err := encodeOperands(e.Then, e.Else)
+		return jsonNode{"kind": "Conditional", "condition": condition, "then": then, "else": elseBranch}, err
+	case Unary:
+		right, err := encodeExpr(e.Right)
+		return jsonNode{"kind": "Unary", "operator": encodeToken(e.Operator), "right": right}, err
+	case Grouping:
+		inner, err := encodeExpr(e.Expression)
+		return jsonNode{"kind": "Grouping", "expression": inner}, err
+	case Literal:
+		return jsonNode{"kind": "Literal", "value": jsonLiteral{e.Value}}, nil
+	case Variable:
+		return jsonNode{"kind": "Variable", "name": encodeToken(e.Name)}, nil
+	case Assign:
+		value, err := encodeExpr(e.Value)
+		return jsonNode{"kind": "Assign", "name": encodeToken(e.Name), "value": value}, err
+	case CompoundAssign:
+		value, err := encodeExpr(e.Value)
+		return jsonNode{"kind": "CompoundAssign", "name": encodeToken(e.Name), "operator": encodeToken(e.Operator), "value": value}, err
+	case Increment:
+		return jsonNode{"kind": "Increment", "name": encodeToken(e.Name), "operator": encodeToken(e.Operator), "prefix": e.Prefix}, nil
+	case Call:
+		callee, err := encodeExpr(e.Callee)
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := encodeExprs(e.Arguments)
+		return jsonNode{"kind": "Call", "callee": callee, "paren": encodeToken(e.Paren), "arguments": arguments}, err
+	case Interpolation:
+		parts, err := encodeExprs(e.Parts)
+		return jsonNode{"kind": "Interpolation", "parts": parts}, err
+	}
+
+	return nil, fmt.Errorf("cannot encode expression of type %T", expr)
+}
+
+func encodeOptionalExpr(expr *Expr) (jsonNode, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	return encodeExpr(*expr)
+}
+
+func encodeExprs(exprs []Expr) ([]jsonNode, error) {
+	nodes := make([]jsonNode, len(exprs))
+	for i, expr := range exprs {
+		node, err := encodeExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
+
+func encodeOperands(left Expr, right Expr) (jsonNode, jsonNode, error) {
+	l, err := encodeExpr(left)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := encodeExpr(right)
+	return l, r, err
+}
+
+// Decoding
+
+// astDecoder reads nodes, taking the IDs for variable references from the
+// parser so they're unique among those it parses
+type astDecoder struct {
+	parser *Parser
+}
+
+type rawNode map[string]json.RawMessage
+
+func (n rawNode) kind() string {
+	var kind string
+	json.Unmarshal(n["kind"], &kind)
+	return kind
+}
+
+func (n rawNode) isNull(field string) bool {
+	raw, ok := n[field]
+	return !ok || string(raw) == "null"
+}
+
+// optionalString reads a string field, which is empty when it's missing
+func (n rawNode) optionalString(field string) (string, error) {
+	var value string
+	if n.isNull(field) {
+		return value, nil
+	}
+	if err := json.Unmarshal(n[field], &value); err != nil {
+		return "", fmt.Errorf("%s.%s: %w", n.kind(), field, err)
+	}
+	return value, nil
+}
+
+func (n rawNode) token(field string) (Token, error) {
+	var token jsonToken
+	if err := json.Unmarshal(n[field], &token); err != nil {
+		return Token{}, fmt.Errorf("%s.%s: %w", n.kind(), field, err)
+	}
+	return token.token(), nil
+}
+
+func (n rawNode) tokens(field string) ([]Token, error) {
+	var encoded []jsonToken
+	if err := json.Unmarshal(n[field], &encoded); err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", n.kind(), field, err)
+	}
+	tokens := make([]Token, len(encoded))
+	for i, token := range encoded {
+		tokens[i] = token.token()
+	}
+	return tokens, nil
+}
+
+func (d astDecoder) stmt(n rawNode, field string) (Stmt, error) {
+	return d.decodeStmt(n[field])
+}
+
+func (d astDecoder) stmts(n rawNode, field string) ([]Stmt, error) {
+	var nodes []json.RawMessage
+	if err := json.Unmarshal(n[field], &nodes); err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", n.kind(), field, err)
+	}
+	return d.decodeStmts(nodes)
+}
+
+func (d astDecoder) expr(n rawNode, field string) (Expr, error) {
+	return d.decodeExpr(n[field])
+}
+
+func (d astDecoder) optionalExpr(n rawNode, field string) (*Expr, error) {
+	if n.isNull(field) {
+		return nil, nil
+	}
+	expr, err := d.expr(n, field)
+	if err != nil {
+		return nil, err
+	}
+	return &expr, nil
+}
+
+func (d astDecoder) exprs(n rawNode, field string) ([]Expr, error) {
+	var nodes []json.RawMessage
+	if err := json.Unmarshal(n[field], &nodes); err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", n.kind(), field, err)
+	}
+	exprs := make([]Expr, len(nodes))
+	for i, node := range nodes {
+		expr, err := d.decodeExpr(node)
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = expr
+	}
+	return exprs, nil
+}
+
+func decodeNode(data json.RawMessage) (rawNode, error) {
+	var node rawNode
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, fmt.Errorf("expected a node, got null")
+	}
+	return node, nil
+}
+
+func (d astDecoder) decodeStmts(nodes []json.RawMessage) ([]Stmt, error) {
+	stmts := make([]Stmt, len(nodes))
+	for i, node := range nodes {
+		stmt, err := d.decodeStmt(node)
+		if err != nil {
+			return nil, err
+		}
+		stmts[i] = stmt
+	}
+	return stmts, nil
+}
+
+func (d astDecoder) decodeStmt(data json.RawMessage) (Stmt, error) {
+	node, err := decodeNode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.kind() {
+	case "Expression":
+		expr, err := d.expr(node, "expression")
+		return Expression{expr}, err
+	case "Print":
+		expr, err := d.expr(node, "expression")
+		return Print{expr}, err
+	case "Var":
+		name, err := node.token("name")
+		if err != nil {
+			return nil, err
+		}
+		init, err := d.optionalExpr(node, "initialiser")
+		if err != nil {
+			return nil, err
+		}
+		doc, err := node.optionalString("doc")
+		return Var{name, init, doc}, err
+	case "Block":
+		stmts, err := d.stmts(node, "statements")
+		return Block{stmts}, err
+	case "If":
+		condition, err := d.expr(node, "condition")
+		if err != nil {
+			return nil, err
+		}
+		then, err := d.stmt(node, "then")
+		if err != nil {
+			return nil, err
+		}
+		var elseBranch Stmt
+		if !node.isNull("else") {
+			elseBranch, err = d.stmt(node, "else")
+		}
+		return If{condition, then, elseBranch}, err
+	case "While":
+		condition, err := d.expr(node, "condition")
+		if err != nil {
+			return nil, err
+		}
+		body, err := d.stmt(node, "body")
+		return While{condition, body}, err
+	case "Function":
+		name, err := node.token("name")
+		if err != nil {
+			return nil, err
+		}
+		params, err := node.tokens("params")
+		if err != nil {
+			return nil, err
+		}
+		body, err := d.stmt(node, "body")
+		if err != nil {
+			return nil, err
+		}
+		block, ok := body.(Block)
+		if !ok {
+			return nil, fmt.Errorf("Function.body: expected a Block, got %T", body)
+		}
+		doc, err := node.optionalString("doc")
+		return Function{name, params, block, doc}, err
+	case "Return":
+		keyword, err := node.token("keyword")
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.optionalExpr(node, "value")
+		return Return{keyword, value}, err
+	}
+
+	return nil, fmt.Errorf("unknown statement kind %q", node.kind())
+}
+
+func (d astDecoder) decodeExpr(data json.RawMessage) (Expr, error) {
+	node, err := decodeNode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.kind() {
+	case "Binary", "Logical":
+		left, err := d.expr(node, "left")
+		if err != nil {
+			return nil, err
+		}
+		operator, err := node.token("operator")
+		if err != nil {
+			return nil, err
+		}
+		right, err := d.expr(node, "right")
+		if node.kind() == "Logical" {
+			return Logical{left, operator, right}, err
+		}
+		return Binary{left, operator, right}, err
+	case "Conditional":
+		condition, err := d.expr(node, "condition")
+		if err != nil {
+			return nil, err
+		}
+		then, err := d.expr(node, "then")
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := d.expr(node, "else")
+		return Conditional{condition, then, elseBranch}, err
+	case "Unary":
+		operator, err := node.token("operator")
+		if err != nil {
+			return nil, err
+		}
+		right, err := d.expr(node, "right")
+		return Unary{operator, right}, err
+	case "Grouping":
+		expr, err := d.expr(node, "expression")
+		return Grouping{expr}, err
+	case "Literal":
+		var value jsonLiteral
+		if err := json.Unmarshal(node["value"], &value); err != nil {
+			return nil, fmt.Errorf("Literal.value: %w", err)
+		}
+		return Literal{value.value}, nil
+	case "Variable":
+		name, err := node.token("name")
+		if err != nil {
+			return nil, err
+		}
+		return Variable{name, d.parser.nextID()}, nil
+	case "Assign":
+		name, err := node.token("name")
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.expr(node, "value")
+		if err != nil {
+			return nil, err
+		}
+		return Assign{name, value, d.parser.nextID()}, nil
+	case "CompoundAssign":
+		name, err := node.token("name")
+		if err != nil {
+			return nil, err
+		}
+		operator, err := node.token("operator")
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.expr(node, "value")
+		if err != nil {
+			return nil, err
+		}
+		return CompoundAssign{name, operator, value, d.parser.nextID()}, nil
+	case "Increment":
+		name, err := node.token("name")
+		if err != nil {
+			return nil, err
+		}
+		operator, err := node.token("operator")
+		if err != nil {
+			return nil, err
+		}
+		var prefix bool
+		if err := json.Unmarshal(node["prefix"], &prefix); err != nil {
+			return nil, fmt.Errorf("Increment.prefix: %w", err)
+		}
+		return Increment{name, operator, prefix, d.parser.nextID()}, nil
+	case "Call":
+		callee, err := d.expr(node, "callee")
+		if err != nil {
+			return nil, err
+		}
+		paren, err := node.token("paren")
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := d.exprs(node, "arguments")
+		return Call{callee, paren, arguments}, err
+	case "Interpolation":
+		parts, err := d.exprs(node, "parts")
+		return Interpolation{parts}, err
+	}
+
+	return nil, fmt.Errorf("unknown expression kind %q", node.kind())
+}
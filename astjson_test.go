@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestASTJSONRoundTrip(t *testing.T) {
+	scripts, err := filepath.Glob("testdata/*/*.lox")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, script := range scripts {
+		t.Run(script, func(t *testing.T) {
+			source, err := os.ReadFile(script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parseExpectations(string(source)).errorType == "CompileError" {
+				t.Skip("script doesn't compile")
+			}
+			ast, err := NewLox().Parse(string(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			encoded, err := MarshalAST(ast)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := UnmarshalAST(encoded, NewParser())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The IDs of variable references are given out afresh, so
+			// the trees are compared through their JSON
+			reencoded, err := MarshalAST(decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encoded, reencoded) {
+				t.Errorf("round trip changed the tree\nbefore: %s\nafter:  %s", NewAstPrinter().Print(ast), NewAstPrinter().Print(decoded))
+			}
+
+			var fromSource, fromJSON strings.Builder
+			captureStderr(t, func() {
+				NewLox(WithOutput(&fromSource)).Run(string(source))
+				NewLox(WithOutput(&fromJSON)).RunAST(encoded)
+			})
+			if fromSource.String() != fromJSON.String() {
+				t.Errorf("running the loaded tree printed %q, expected %q", fromJSON.String(), fromSource.String())
+			}
+		})
+	}
+}
+
+func TestUnmarshalASTErrors(t *testing.T) {
+	cases := map[string]string{
+		"not an array":   `{"kind": "Print"}`,
+		"unknown stmt":   `[{"kind": "Loop"}]`,
+		"unknown expr":   `[{"kind": "Print", "expression": {"kind": "Lambda"}}]`,
+		"null expr":      `[{"kind": "Print", "expression": null}]`,
+		"non-block body": `[{"kind": "Function", "name": {"type": "IDENTIFIER", "lexeme": "f"}, "params": [], "body": {"kind": "Print", "expression": {"kind": "Literal", "value": 1}}}]`,
+	}
+
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := UnmarshalAST([]byte(input), NewParser()); err == nil {
+				t.Errorf("expected an error decoding %s", input)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// AstPrinter renders syntax trees as S-expressions, e.g. (print (+ 1 2))
+func NewAstPrinter() *AstPrinter {
+	return &AstPrinter{}
+}
+
+var _ Visitor = &AstPrinter{}
+
+// Statement visitors can't return a value, so the rendering of the most
+// recently visited statement is kept in result
+type AstPrinter struct {
+	result string
+}
+
+func (a *AstPrinter) Print(stmts []Stmt) string {
+	lines := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		lines[i] = a.printStmt(stmt)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (a *AstPrinter) PrintExpr(expr Expr) string {
+	value, _ := expr.Accept(a)
+	return value.(string)
+}
+
+func (a *AstPrinter) printStmt(stmt Stmt) string {
+	stmt.Accept(a)
+	return a.result
+}
+
+func (a *AstPrinter) parenthesize(name string, parts ...string) string {
+	if len(parts) == 0 {
+		return "(" + name + ")"
+	}
+	return "(" + name + " " + strings.Join(parts, " ") + ")"
+}
+
+func (a *AstPrinter) exprs(exprs ...Expr) []string {
+	parts := make([]string, len(exprs))
+	for i, expr := range exprs {
+		parts[i] = a.PrintExpr(expr)
+	}
+	return parts
+}
+
+func (a *AstPrinter) stmts(stmts ...Stmt) []string {
+	parts := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		parts[i] = a.printStmt(stmt)
+	}
+	return parts
+}
+
+// Expressions
+
+func (a *AstPrinter) VisitBinaryExpr(expr Binary) (interface{}, LoxError) {
+	return a.parenthesize(expr.Operator.lexeme, a.exprs(expr.Left, expr.Right)...), nil
+}
+
+func (a *AstPrinter) VisitUnaryExpr(expr Unary) (interface{}, LoxError) {
+	return a.parenthesize(expr.Operator.lexeme, a.exprs(expr.Right)...), nil
+}
+
+func (a *AstPrinter) VisitGroupingExpr(expr Grouping) (interface{}, LoxError) {
+	return a.parenthesize("group", a.exprs(expr.Expression)...), nil
+}
+
+func (a *AstPrinter) VisitLiteralExpr(expr Literal) (interface{}, LoxError) {
+	switch value := expr.Value.(type) {
+	case string:
+		return strconv.Quote(value), nil
+	case *big.Int:
+		return value.String() + "n", nil
+	case *Decimal:
+		return value.String() + "m", nil
+	}
+	return stringify(expr.Value), nil
+}
+
+func (a *AstPrinter) VisitVariableExpr(expr Variable) (interface{}, LoxError) {
+	return expr.Name.lexeme, nil
+}
+
+func (a *AstPrinter) VisitAssignExpr(expr Assign) (interface{}, LoxError) {
+	return a.parenthesize("=", expr.Name.lexeme, a.PrintExpr(expr.Value)), nil
+}
+
+func (a *AstPrinter) VisitCompoundAssignExpr(expr CompoundAssign) (interface{}, LoxError) {
+	return a.parenthesize(expr.Operator.lexeme, expr.Name.lexeme, a.PrintExpr(expr.Value)), nil
+}
+
+func (a *AstPrinter) VisitIncrementExpr(expr Increment) (interface{}, LoxError) {
+	if expr.Prefix {
+		return a.parenthesize("pre"+expr.Operator.lexeme, expr.Name.lexeme), nil
+	}
+	return a.parenthesize("post"+expr.Operator.lexeme, expr.Name.lexeme), nil
+}
+
+func (a *AstPrinter) VisitInterpolationExpr(expr Interpolation) (interface{}, LoxError) {
+	return a.parenthesize("interpolate", a.exprs(expr.Parts...)...), nil
+}
+
+func (a *AstPrinter) VisitConditionalExpr(expr Conditional) (interface{}, LoxError) {
+	return a.parenthesize("?:", a.exprs(expr.Condition, expr.Then, expr.Else)...), nil
+}
+
+func (a *AstPrinter) VisitLogicalExpr(expr Logical) (interface{}, LoxError) {
+	return a.parenthesize(expr.Operator.lexeme, a.exprs(expr.Left, expr.Right)...), nil
+}
+
+func (a *AstPrinter) VisitCallExpr(expr Call) (interface{}, LoxError) {
+	parts := append([]string{a.PrintExpr(expr.Callee)}, a.exprs(expr.Arguments...)...)
+	return a.parenthesize("call", parts...), nil
+}
+
+// Statements
+
+func (a *AstPrinter) VisitExpressionStmt(stmt Expression) LoxError {
+	a.result = a.parenthesize(";", a.PrintExpr(stmt.Expression))
+	return nil
+}
+
+func (a *AstPrinter) VisitPrintStmt(stmt Print) LoxError {
+	a.result = a.parenthesize("print", a.PrintExpr(stmt.Expression))
+	return nil
+}
+
+func (a *AstPrinter) VisitVarStmt(stmt Var) LoxError {
+	parts := []string{stmt.Name.lexeme}
+	if stmt.Initialiser != nil {
+		parts = append(parts, a.PrintExpr(*stmt.Initialiser))
+	}
+	a.result = a.parenthesize("var", parts...)
+	return nil
+}
+
+func (a *AstPrinter) VisitBlockStmt(stmt Block) LoxError {
+	a.result = a.parenthesize("block", a.stmts(stmt.Statements...)...)
+	return nil
+}
+
+func (a *AstPrinter) VisitIfStmt(stmt If) LoxError {
+	parts := []string{a.PrintExpr(stmt.Condition), a.printStmt(stmt.Then)}
+	if stmt.Else != nil {
+		parts = append(parts, a.printStmt(stmt.Else))
+	}
+	a.result = a.parenthesize("if", parts...)
+	return nil
+}
+
+func (a *AstPrinter) VisitWhileStmt(stmt While) LoxError {
+	a.result = a.parenthesize("while", a.PrintExpr(stmt.Condition), a.printStmt(stmt.Body))
+	return nil
+}
+
+func (a *AstPrinter) VisitFunctionStmt(stmt Function) LoxError {
+	params := make([]string, len(stmt.Params))
+	for i, param := range stmt.Params {
+		params[i] = param.lexeme
+	}
+
+	parts := []string{stmt.Name.lexeme, fmt.Sprintf("(%s)", strings.Join(params, " "))}
+	parts = append(parts, a.stmts(stmt.Body.Statements...)...)
+	a.result = a.parenthesize("fun", parts...)
+	return nil
+}
+
+func (a *AstPrinter) VisitReturnStmt(stmt Return) LoxError {
+	if stmt.Value == nil {
+		a.result = a.parenthesize("return")
+	} else {
+		a.result = a.parenthesize("return", a.PrintExpr(*stmt.Value))
+	}
+	return nil
+}
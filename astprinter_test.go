@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestAstPrinter(t *testing.T) {
+	cases := map[string]string{
+		`print 1 + 2 * 3;`:                  `(print (+ 1 (* 2 3)))`,
+		`var a = "hi";`:                     `(var a "hi")`,
+		`var b;`:                            `(var b)`,
+		`a = -(b) or nil;`:                  `(; (= a (or (- (group b)) nil)))`,
+		`if (a) print a; else { f(1, 2); }`: `(if a (print a) (block (; (call f 1 2))))`,
+		`while (true) a = a;`:               `(while true (; (= a a)))`,
+		`fun f(x, y) { return x; }`:         `(fun f (x y) (return x))`,
+		`a += b *= 2;`:                      `(; (+= a (*= b 2)))`,
+		`print ++a + b--;`:                  `(print (+ (pre++ a) (post-- b)))`,
+		`print a ? b : c ? d : e;`:          `(print (?: a b (?: c d e)))`,
+		`print a ?? b or c;`:                `(print (?? a (or b c)))`,
+		`print "a ${b + 1} c${d}";`:         `(print (interpolate "a " (+ b 1) " c" d))`,
+	}
+
+	for source, expected := range cases {
+		ast, err := NewLox().Parse(source)
+		if err != nil {
+			t.Fatalf("%s: %s", source, err)
+		}
+		if actual := NewAstPrinter().Print(ast); actual != expected {
+			t.Errorf("%s: expected %s, got %s", source, expected, actual)
+		}
+	}
+}
@@ -2,17 +2,21 @@ package main
 
 import (
 	"fmt"
-	"time"
+	"math/big"
 )
 
 type Callable interface {
-	Call(*Interpreter, []interface{}) (interface{}, LoxError)
+	// Call is given the closing parenthesis of the call, for reporting errors
+	Call(*Interpreter, Token, []interface{}) (interface{}, LoxError)
 
+	// Arity is the number of arguments expected, or variadic to accept any
 	Arity() int
 
 	String() string
 }
 
+const variadic = -1
+
 var _ Callable = &LoxFunction{}
 
 type LoxFunction struct {
@@ -20,7 +24,7 @@ type LoxFunction struct {
 	closure     *Environment
 }
 
-func (f *LoxFunction) Call(interpreter *Interpreter, args []interface{}) (interface{}, LoxError) {
+func (f *LoxFunction) Call(interpreter *Interpreter, paren Token, args []interface{}) (interface{}, LoxError) {
 	environment := NewScopedEnvironment(f.closure)
 
 	for i, param := range f.declaration.Params {
@@ -45,21 +49,124 @@ func (f *LoxFunction) String() string {
 // Native functions
 
 func native() map[string]interface{} {
-	return map[string]interface{}{
-		"clock": Clock{},
+	globals := make(map[string]interface{})
+
+	libraries := [][]*NativeFunction{
+		mathNatives(),
+		stringNatives(),
+		listNatives(),
+		mapNatives(),
+		convertNatives(),
+		fileNatives(),
+		processNatives(),
+		jsonNatives(),
+		regexNatives(),
+		randomNatives(),
+		timeNatives(),
+	}
+	for _, library := range libraries {
+		for _, function := range library {
+			globals[function.name] = function
+		}
 	}
+	for name, value := range mathConstants() {
+		globals[name] = value
+	}
+
+	return globals
 }
 
-type Clock struct{}
+var _ Callable = &NativeFunction{}
 
-func (c Clock) Arity() int {
-	return 0
+// NativeFunction is a function implemented in Go. Arguments are type checked
+// by the function itself, using the argument helpers below
+type NativeFunction struct {
+	name  string
+	arity int
+	fn    func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError)
 }
 
-func (c Clock) Call(interpreter Visitor, arguments []interface{}) (interface{}, LoxError) {
-	return time.Now().Unix(), nil
+func (n *NativeFunction) Call(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	return n.fn(interpreter, paren, arguments)
 }
 
-func (c Clock) String() string {
+func (n *NativeFunction) Arity() int {
+	return n.arity
+}
+
+func (n *NativeFunction) String() string {
 	return "<native fn>"
 }
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case int64, *big.Int, *Decimal, float64:
+		return "number"
+	case string:
+		return "string"
+	case *LoxList:
+		return "list"
+	case *LoxMap:
+		return "map"
+	case Callable:
+		return "function"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func argumentError(paren Token, name string, index int, expected string, value interface{}) LoxError {
+	return RuntimeError{paren, fmt.Sprintf("Argument %d to %s must be a %s, got %s", index+1, name, expected, typeName(value))}
+}
+
+// numberArgument accepts either kind of number, as a float
+func numberArgument(paren Token, name string, arguments []interface{}, index int) (float64, LoxError) {
+	number, ok := toFloat(arguments[index])
+	if !ok {
+		return 0, argumentError(paren, name, index, "number", arguments[index])
+	}
+	return number, nil
+}
+
+// integerArgument accepts integers, and floats without a fractional part
+func integerArgument(paren Token, name string, arguments []interface{}, index int) (int, LoxError) {
+	switch number := arguments[index].(type) {
+	case int64:
+		return int(number), nil
+	case *big.Int:
+		if number.IsInt64() {
+			return int(number.Int64()), nil
+		}
+	case float64:
+		if whole, ok := wholeFloat(number); ok {
+			return int(whole), nil
+		}
+	}
+	return 0, argumentError(paren, name, index, "whole number", arguments[index])
+}
+
+func listArgument(paren Token, name string, arguments []interface{}, index int) (*LoxList, LoxError) {
+	list, ok := arguments[index].(*LoxList)
+	if !ok {
+		return nil, argumentError(paren, name, index, "list", arguments[index])
+	}
+	return list, nil
+}
+
+func stringArgument(paren Token, name string, arguments []interface{}, index int) (string, LoxError) {
+	str, ok := arguments[index].(string)
+	if !ok {
+		return "", argumentError(paren, name, index, "string", arguments[index])
+	}
+	return str, nil
+}
+
+func minArguments(paren Token, name string, arguments []interface{}, min int) LoxError {
+	if len(arguments) < min {
+		return RuntimeError{paren, fmt.Sprintf("%s expects at least %d arguments, got %d", name, min, len(arguments))}
+	}
+	return nil
+}
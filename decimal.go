@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact fixed-point number, unscaled / 10^scale. The scale is
+// the number of digits after the decimal point, which a decimal keeps when
+// printed, so 1.50m prints as 1.50. Decimals are immutable.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// divisionScale is the least number of decimal places a quotient is worked
+// out to, as division is the one operation that can't always be exact
+const divisionScale = 16
+
+var ten = big.NewInt(10)
+
+// maxDecimalExponent bounds the exponent a decimal can be written with, and
+// the places one can be rounded to, as the digits they stand for are built
+// in full
+const maxDecimalExponent = 10000
+
+var (
+	errDecimalSyntax = errors.New("invalid decimal")
+	errDecimalRange  = fmt.Errorf("decimal exponent must be within ±%d", maxDecimalExponent)
+)
+
+func NewDecimal(unscaled *big.Int, scale int) *Decimal {
+	return &Decimal{unscaled, scale}
+}
+
+// parseDecimal reads a decimal written as digits with an optional sign,
+// fraction and exponent, keeping every digit given
+func parseDecimal(text string) (*Decimal, error) {
+	mantissa, exponent := text, 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		e, err := strconv.Atoi(text[i+1:])
+		if errors.Is(err, strconv.ErrRange) {
+			return nil, errDecimalRange
+		}
+		if err != nil {
+			return nil, errDecimalSyntax
+		}
+		if e > maxDecimalExponent || e < -maxDecimalExponent {
+			return nil, errDecimalRange
+		}
+		mantissa, exponent = text[:i], e
+	}
+
+	scale := 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = len(mantissa) - i - 1
+		mantissa = mantissa[:i] + mantissa[i+1:]
+	}
+
+	unscaled, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return nil, errDecimalSyntax
+	}
+
+	scale -= exponent
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return NewDecimal(unscaled, scale), nil
+}
+
+// decimalFromFloat uses the shortest decimal that reads back as the same
+// float, so 0.1 becomes 0.1 rather than its exact binary value
+func decimalFromFloat(f float64) (*Decimal, bool) {
+	d, err := parseDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+	return d, err == nil
+}
+
+func decimalFromInt(n *big.Int) *Decimal {
+	return NewDecimal(n, 0)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(n)), nil)
+}
+
+// rescaled is the unscaled value at a larger scale
+func (d *Decimal) rescaled(scale int) *big.Int {
+	if scale == d.scale {
+		return d.unscaled
+	}
+	return new(big.Int).Mul(d.unscaled, pow10(scale-d.scale))
+}
+
+func (d *Decimal) Add(other *Decimal) *Decimal {
+	scale := maxInt(d.scale, other.scale)
+	return NewDecimal(new(big.Int).Add(d.rescaled(scale), other.rescaled(scale)), scale)
+}
+
+func (d *Decimal) Sub(other *Decimal) *Decimal {
+	scale := maxInt(d.scale, other.scale)
+	return NewDecimal(new(big.Int).Sub(d.rescaled(scale), other.rescaled(scale)), scale)
+}
+
+func (d *Decimal) Mul(other *Decimal) *Decimal {
+	return NewDecimal(new(big.Int).Mul(d.unscaled, other.unscaled), d.scale+other.scale)
+}
+
+// Quo divides to at least divisionScale places, rounding the last, then drops
+// trailing zeros beyond the scale of either operand
+func (d *Decimal) Quo(other *Decimal) *Decimal {
+	scale := maxInt(maxInt(d.scale, other.scale), divisionScale)
+	quotient := new(big.Rat).Quo(d.Rat(), other.Rat())
+	return roundRat(quotient, scale).trim(maxInt(d.scale, other.scale))
+}
+
+// Rem is the remainder of truncated division, taking the sign of d
+func (d *Decimal) Rem(other *Decimal) *Decimal {
+	scale := maxInt(d.scale, other.scale)
+	return NewDecimal(new(big.Int).Rem(d.rescaled(scale), other.rescaled(scale)), scale)
+}
+
+func (d *Decimal) Neg() *Decimal {
+	return NewDecimal(new(big.Int).Neg(d.unscaled), d.scale)
+}
+
+func (d *Decimal) Sign() int {
+	return d.unscaled.Sign()
+}
+
+// Round gives exactly places digits after the decimal point, rounding halves
+// away from zero
+func (d *Decimal) Round(places int) *Decimal {
+	if places >= d.scale {
+		return NewDecimal(d.rescaled(places), places)
+	}
+	return roundRat(d.Rat(), places)
+}
+
+// Floor rounds toward negative infinity to a whole number
+func (d *Decimal) Floor() *Decimal {
+	return NewDecimal(new(big.Int).Div(d.unscaled, pow10(d.scale)), 0)
+}
+
+// Ceil rounds toward positive infinity to a whole number
+func (d *Decimal) Ceil() *Decimal {
+	return d.Neg().Floor().Neg()
+}
+
+// Trunc rounds toward zero to a whole number
+func (d *Decimal) Trunc() *Decimal {
+	return NewDecimal(new(big.Int).Quo(d.unscaled, pow10(d.scale)), 0)
+}
+
+// trim drops trailing zeros from the fraction, down to at least scale places
+func (d *Decimal) trim(scale int) *Decimal {
+	unscaled, places := new(big.Int).Set(d.unscaled), d.scale
+	remainder := new(big.Int)
+	for places > scale {
+		quotient, r := new(big.Int).QuoRem(unscaled, ten, remainder)
+		if r.Sign() != 0 {
+			break
+		}
+		unscaled, places = quotient, places-1
+	}
+	return NewDecimal(unscaled, places)
+}
+
+func roundRat(r *big.Rat, places int) *Decimal {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(places)))
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+
+	// Round away from zero when the remainder is at least half the divisor
+	twice := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+	if twice.Cmp(scaled.Denom()) >= 0 {
+		if scaled.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return NewDecimal(quotient, places)
+}
+
+func (d *Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+func (d *Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(d.unscaled).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if d.unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Format supports %f, %s and %v with the usual flags, width and precision,
+// without going through a float. Without a precision, %f keeps the decimal's
+// own scale
+func (d *Decimal) Format(state fmt.State, verb rune) {
+	value := d
+	if precision, ok := state.Precision(); ok && verb == 'f' {
+		value = d.Round(precision)
+	}
+
+	text := value.String()
+	if value.Sign() >= 0 && state.Flag('+') {
+		text = "+" + text
+	} else if value.Sign() >= 0 && state.Flag(' ') {
+		text = " " + text
+	}
+
+	if width, ok := state.Width(); ok && len(text) < width {
+		padding := width - len(text)
+		switch {
+		case state.Flag('-'):
+			text += strings.Repeat(" ", padding)
+		case state.Flag('0'):
+			sign := ""
+			if strings.ContainsAny(text[:1], "+- ") {
+				sign, text = text[:1], text[1:]
+			}
+			text = sign + strings.Repeat("0", padding) + text
+		default:
+			text = strings.Repeat(" ", padding) + text
+		}
+	}
+	io.WriteString(state, text)
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
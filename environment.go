@@ -2,8 +2,10 @@ package main
 
 import "fmt"
 
+// Natives are kept in an environment enclosing the globals, so that the
+// globals only hold what scripts define
 func NewGlobalEnvironment() *Environment {
-	return &Environment{nil, native()}
+	return NewScopedEnvironment(&Environment{nil, native()})
 }
 
 func NewScopedEnvironment(from *Environment) *Environment {
@@ -1,5 +1,7 @@
 package main
 
+import "fmt"
+
 type LoxError interface {
 	Type() string
 	Token() Token
@@ -66,3 +68,21 @@ func (r ReturnError) Token() Token {
 func (r ReturnError) Error() string {
 	return "Return"
 }
+
+// Also not an error, but unwinds the whole program when a script calls exit
+type ExitError struct {
+	Code      int
+	ExitToken Token
+}
+
+func (e ExitError) Type() string {
+	return "ExitError"
+}
+
+func (e ExitError) Token() Token {
+	return e.ExitToken
+}
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("Exit with status %d", e.Code)
+}
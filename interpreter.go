@@ -1,14 +1,36 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"math/rand"
+	"os"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func NewInterpreter() *Interpreter {
+func NewInterpreter(options ...Option) *Interpreter {
 	globals := NewGlobalEnvironment()
 
-	return &Interpreter{globals, globals, make(map[Expr]int64)}
+	interpreter := &Interpreter{
+		environment: globals,
+		globals:     globals,
+		locals:      make(map[ExprID]int64),
+		patterns:    make(map[string]*regexp.Regexp),
+		random:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		clock:       systemClock{},
+		out:         os.Stdout,
+	}
+	for _, option := range options {
+		option(interpreter)
+	}
+	interpreter.started = interpreter.clock.Now()
+	return interpreter
 }
 
 var _ Visitor = (&Interpreter{})
@@ -16,12 +38,90 @@ var _ Visitor = (&Interpreter{})
 type Interpreter struct {
 	environment *Environment
 	globals     *Environment
-	locals      map[Expr]int64
+	locals      map[ExprID]int64
+
+	// out is where print writes
+	out io.Writer
+
+	// files is nil unless the host allows file access
+	files *FileSystem
+	stdin *bufio.Reader
+	args  []string
+	env   func(string) (string, bool)
+
+	// patterns caches compiled regular expressions by their source
+	patterns map[string]*regexp.Regexp
+
+	// random backs the random natives, and is reseeded by seed
+	random *rand.Rand
+
+	// clock is where the time natives get the time, and started is when the
+	// interpreter was created, for monotonic
+	clock   Clock
+	started time.Time
+}
+
+// Option configures what an interpreter's natives have access to
+type Option func(*Interpreter)
+
+// WithOutput sends what scripts print to out instead of stdout
+func WithOutput(out io.Writer) Option {
+	return func(i *Interpreter) {
+		i.out = out
+	}
+}
+
+// WithStdin gives scripts a reader for readLine and readAll
+func WithStdin(stdin io.Reader) Option {
+	return func(i *Interpreter) {
+		i.stdin = bufio.NewReader(stdin)
+	}
+}
+
+// WithArgs sets the command line arguments returned by args
+func WithArgs(args []string) Option {
+	return func(i *Interpreter) {
+		i.args = args
+	}
+}
+
+// WithEnvironment lets scripts look up environment variables, such as with
+// os.LookupEnv
+func WithEnvironment(lookup func(string) (string, bool)) Option {
+	return func(i *Interpreter) {
+		i.env = lookup
+	}
+}
+
+// WithSeed seeds the random natives, making runs reproducible
+func WithSeed(seed int64) Option {
+	return func(i *Interpreter) {
+		i.random.Seed(seed)
+	}
+}
+
+// WithClock replaces the system clock used by the time natives
+func WithClock(clock Clock) Option {
+	return func(i *Interpreter) {
+		i.clock = clock
+	}
+}
+
+// WithFileSystem lets scripts read from files, and write beneath writeRoot
+// unless it's empty. Use NewDirFS rather than os.DirFS to read from disk, as
+// it keeps symlinks from leading out of the directory
+func WithFileSystem(files fs.FS, writeRoot string) Option {
+	return func(i *Interpreter) {
+		i.files = &FileSystem{files, writeRoot}
+	}
 }
 
 func (i *Interpreter) Interpret(statements []Stmt) error {
 	for _, stmt := range statements {
 		err := i.execute(stmt)
+		if _, ok := err.(ExitError); ok {
+			return err
+		}
 		if err != nil {
 			report(err, err.Token().line)
 			return err
@@ -30,6 +130,20 @@ func (i *Interpreter) Interpret(statements []Stmt) error {
 	return nil
 }
 
+// Evaluate reports errors in the same way as Interpret, but returns the
+// expression's value
+func (i *Interpreter) Evaluate(expr Expr) (interface{}, error) {
+	value, err := i.evaluate(expr)
+	if _, ok := err.(ExitError); ok {
+		return nil, err
+	}
+	if err != nil {
+		report(err, err.Token().line)
+		return nil, err
+	}
+	return value, nil
+}
+
 // Visitor methods
 
 func (i *Interpreter) VisitPrintStmt(stmt Print) LoxError {
@@ -38,7 +152,7 @@ func (i *Interpreter) VisitPrintStmt(stmt Print) LoxError {
 		return err
 	}
 
-	fmt.Println(stringify(value))
+	fmt.Fprintln(i.out, stringify(value))
 	return nil
 }
 
@@ -63,11 +177,9 @@ func (i *Interpreter) VisitUnaryExpr(expr Unary) (interface{}, LoxError) {
 
 	switch expr.Operator.tokenType {
 	case MINUS:
-		num, err := checkNumber(expr.Operator, right)
-		if err != nil {
-			return nil, err
-		}
-		return -num, nil
+		return negate(expr.Operator, right)
+	case TILDE:
+		return bitwiseNot(expr.Operator, right)
 	case BANG:
 		return !i.isTruthy(right), nil
 	}
@@ -94,10 +206,10 @@ func (i *Interpreter) VisitCallExpr(expr Call) (interface{}, LoxError) {
 	if !ok {
 		return nil, RuntimeError{expr.Paren, fmt.Sprintf("Can only call functions and classes")}
 	}
-	if len(arguments) != function.Arity() {
-		return nil, RuntimeError{expr.Paren, fmt.Sprintf("Expected %d arguments, got %d", function.Arity(), len(arguments))}
+	if arity := function.Arity(); arity != variadic && len(arguments) != arity {
+		return nil, RuntimeError{expr.Paren, fmt.Sprintf("Expected %d arguments, got %d", arity, len(arguments))}
 	}
-	return function.Call(i, arguments)
+	return function.Call(i, expr.Paren, arguments)
 }
 
 func (i *Interpreter) VisitVarStmt(stmt Var) LoxError {
@@ -156,7 +268,7 @@ func (i *Interpreter) VisitReturnStmt(ret Return) LoxError {
 }
 
 func (i *Interpreter) VisitVariableExpr(expr Variable) (interface{}, LoxError) {
-	return i.lookupVariable(expr.Name, expr)
+	return i.lookupVariable(expr.ID, expr.Name)
 }
 
 func (i *Interpreter) VisitBinaryExpr(expr Binary) (interface{}, LoxError) {
@@ -169,58 +281,40 @@ func (i *Interpreter) VisitBinaryExpr(expr Binary) (interface{}, LoxError) {
 		return nil, err
 	}
 
-	switch expr.Operator.tokenType {
-	case MINUS:
-		l, r, err := checkNumbers(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-		return l - r, nil
-	case SLASH:
-		l, r, err := checkNumbers(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-		if r == 0 {
-			return nil, DivideZeroError{RuntimeError{expr.Operator, "Cannot divide by zero"}}
-		}
-		return l / r, nil
-	case STAR:
-		l, r, err := checkNumbers(expr.Operator, left, right)
-		if err != nil {
+	return i.binary(expr.Operator, left, right)
+}
+
+func (i *Interpreter) binary(operator Token, left interface{}, right interface{}) (interface{}, LoxError) {
+	switch operator.tokenType {
+	case MINUS, SLASH, STAR:
+		if err := checkNumbers(operator, left, right); err != nil {
 			return nil, err
 		}
-		return l * r, nil
+		return arithmetic(operator, left, right)
 	case PLUS:
-		leftNum, isLeftNum := left.(float64)
-		rightNum, isRightNum := right.(float64)
-		leftString, isLeftString := left.(string)
-		rightString, isRightString := right.(string)
-
-		if isLeftNum && isRightNum {
-			return leftNum + rightNum, nil
+		if isNumber(left) && isNumber(right) {
+			return arithmetic(operator, left, right)
 		}
 
+		leftString, isLeftString := left.(string)
+		rightString, isRightString := right.(string)
 		if isLeftString && isRightString {
 			return leftString + rightString, nil
 		}
 
-		return nil, &RuntimeError{expr.Operator, "Operands must be two strings or two numbers"}
-	case GREATER:
-		return left.(float64) > right.(float64), nil
-	case GREATER_EQUAL:
-		return left.(float64) >= right.(float64), nil
-	case LESS:
-		return left.(float64) < right.(float64), nil
-	case LESS_EQUAL:
-		return left.(float64) <= right.(float64), nil
+		return nil, &RuntimeError{operator, "Operands must be two strings or two numbers"}
+	case GREATER, GREATER_EQUAL, LESS, LESS_EQUAL:
+		if err := checkNumbers(operator, left, right); err != nil {
+			return nil, err
+		}
+		return compareNumbers(operator, left, right), nil
 	case PERCENT:
-		leftNum, isLeftNum := left.(float64)
-		rightNum, isRightNum := right.(float64)
-		if !isLeftNum || !isRightNum {
-			return nil, RuntimeError{expr.Operator, "Operands must be two numbers"}
+		if !isNumber(left) || !isNumber(right) {
+			return nil, RuntimeError{operator, "Operands must be two numbers"}
 		}
-		return int64(leftNum) % int64(rightNum), nil
+		return arithmetic(operator, left, right)
+	case AMPERSAND, PIPE, CARET, LESS_LESS, GREATER_GREATER:
+		return bitwise(operator, left, right)
 	case BANG_EQUAL:
 		return !i.isEqual(left, right), nil
 	case EQUAL_EQUAL:
@@ -236,12 +330,72 @@ func (i *Interpreter) VisitAssignExpr(expr Assign) (interface{}, LoxError) {
 		return nil, err
 	}
 
-	distance, ok := i.locals[expr]
-	if ok {
-		i.environment.AtDepth(distance).Assign(expr.Name, value)
+	if err := i.assign(expr.ID, expr.Name, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// compoundOperators maps each compound assignment to the binary operator it
+// applies
+var compoundOperators = map[Lexeme]Lexeme{
+	PLUS_EQUAL:    PLUS,
+	MINUS_EQUAL:   MINUS,
+	STAR_EQUAL:    STAR,
+	SLASH_EQUAL:   SLASH,
+	PERCENT_EQUAL: PERCENT,
+}
+
+func (i *Interpreter) VisitCompoundAssignExpr(expr CompoundAssign) (interface{}, LoxError) {
+	current, err := i.lookupVariable(expr.ID, expr.Name)
+	if err != nil {
+		return nil, err
+	}
+	operand, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := expr.Operator
+	operator.tokenType = compoundOperators[operator.tokenType]
+	value, err := i.binary(operator, current, operand)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.assign(expr.ID, expr.Name, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (i *Interpreter) VisitIncrementExpr(expr Increment) (interface{}, LoxError) {
+	current, err := i.lookupVariable(expr.ID, expr.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !isNumber(current) {
+		return nil, RuntimeError{expr.Operator, "Operand must be a number"}
+	}
+
+	operator := expr.Operator
+	if operator.tokenType == PLUS_PLUS {
+		operator.tokenType = PLUS
+	} else {
+		operator.tokenType = MINUS
+	}
+	value, err := arithmetic(operator, current, int64(1))
+	if err != nil {
+		return nil, err
 	}
 
-	return value, err
+	if err := i.assign(expr.ID, expr.Name, value); err != nil {
+		return nil, err
+	}
+	if expr.Prefix {
+		return value, nil
+	}
+	return current, nil
 }
 
 func (i *Interpreter) VisitLogicalExpr(expr Logical) (interface{}, LoxError) {
@@ -250,11 +404,16 @@ func (i *Interpreter) VisitLogicalExpr(expr Logical) (interface{}, LoxError) {
 		return nil, err
 	}
 
-	if expr.Operator.tokenType == OR {
+	switch expr.Operator.tokenType {
+	case OR:
 		if i.isTruthy(left) {
 			return left, nil
 		}
-	} else {
+	case QUESTION_QUESTION:
+		if left != nil {
+			return left, nil
+		}
+	default:
 		if !i.isTruthy(left) {
 			return left, nil
 		}
@@ -263,13 +422,37 @@ func (i *Interpreter) VisitLogicalExpr(expr Logical) (interface{}, LoxError) {
 	return i.evaluate(expr.Right)
 }
 
+func (i *Interpreter) VisitInterpolationExpr(expr Interpolation) (interface{}, LoxError) {
+	var text strings.Builder
+	for _, part := range expr.Parts {
+		value, err := i.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		text.WriteString(stringify(value))
+	}
+	return text.String(), nil
+}
+
+func (i *Interpreter) VisitConditionalExpr(expr Conditional) (interface{}, LoxError) {
+	condition, err := i.evaluate(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if i.isTruthy(condition) {
+		return i.evaluate(expr.Then)
+	}
+	return i.evaluate(expr.Else)
+}
+
 func (i *Interpreter) VisitWhileStmt(stmt While) LoxError {
 	for true {
 		condition, err := i.evaluate(stmt.Condition)
 		if err != nil {
 			return err
 		}
-		if truthy, ok := condition.(bool); !truthy || !ok {
+		if !i.isTruthy(condition) {
 			break
 		}
 
@@ -288,8 +471,11 @@ func (i *Interpreter) execute(stmt Stmt) LoxError {
 	return stmt.Accept(i)
 }
 
-func (i *Interpreter) resolve(expr Expr, depth int64) {
-	i.locals[expr] = depth
+// Locals are keyed by the ID the parser gave the reference rather than the
+// expression, as expressions holding slices (such as calls) can't be used as
+// map keys, and a token can repeat in a later REPL input
+func (i *Interpreter) resolve(id ExprID, depth int64) {
+	i.locals[id] = depth
 }
 
 func (i *Interpreter) executeBlock(stmts []Stmt, env *Environment) LoxError {
@@ -324,7 +510,7 @@ func (i *Interpreter) isTruthy(obj interface{}) bool {
 		return t
 	}
 
-	return false
+	return true
 }
 
 func (i *Interpreter) isEqual(a interface{}, b interface{}) bool {
@@ -334,12 +520,24 @@ func (i *Interpreter) isEqual(a interface{}, b interface{}) bool {
 	if a == nil || b == nil {
 		return false
 	}
+	if isNumber(a) && isNumber(b) {
+		return numbersEqual(a, b)
+	}
 
 	return a == b
 }
 
-func (i *Interpreter) lookupVariable(name Token, expr Expr) (interface{}, LoxError) {
-	distance, ok := i.locals[expr]
+func (i *Interpreter) assign(id ExprID, name Token, value interface{}) LoxError {
+	distance, ok := i.locals[id]
+	if ok {
+		return i.environment.AtDepth(distance).Assign(name, value)
+	} else {
+		return i.globals.Assign(name, value)
+	}
+}
+
+func (i *Interpreter) lookupVariable(id ExprID, name Token) (interface{}, LoxError) {
+	distance, ok := i.locals[id]
 	if ok {
 		return i.environment.AtDepth(distance).Get(name)
 	} else {
@@ -358,40 +556,33 @@ func stringify(obj interface{}) string {
 		return str
 	}
 
+	if num, ok := obj.(int64); ok {
+		return formatInteger(num)
+	}
+
 	if num, ok := obj.(float64); ok {
-		text := fmt.Sprintf("%f", num)
-		reggie := regexp.MustCompile("^(.*)\\.0+$")
-		if reggie.MatchString(text) {
-			text = reggie.FindStringSubmatch(text)[1]
+		switch {
+		case math.IsNaN(num):
+			return "nan"
+		case math.IsInf(num, 1):
+			return "inf"
+		case math.IsInf(num, -1):
+			return "-inf"
 		}
-		reggie2 := regexp.MustCompile("^(.*\\.[1-9]+)0+")
-		if reggie2.MatchString(text) {
-			text = reggie2.FindStringSubmatch(text)[1]
-		}
-		return text
+
+		return strconv.FormatFloat(num, 'f', -1, 64)
 	}
 
 	if str, ok := obj.(fmt.Stringer); ok {
 		return str.String()
 	}
 
-	return fmt.Sprintf("%v+", obj)
-}
-
-func checkNumber(operator Token, value interface{}) (float64, *RuntimeError) {
-	if number, ok := value.(float64); ok {
-		return number, nil
-	}
-	return 0, &RuntimeError{operator, "Operand must be a number"}
+	return fmt.Sprintf("%v", obj)
 }
 
-func checkNumbers(operator Token, a interface{}, b interface{}) (aNum float64, bNum float64, err *RuntimeError) {
-	aNum, aOk := a.(float64)
-	bNum, bOk := b.(float64)
-
-	if !aOk || !bOk {
-		err = &RuntimeError{operator, "Must be a number"}
+func checkNumbers(operator Token, a interface{}, b interface{}) LoxError {
+	if !isNumber(a) || !isNumber(b) {
+		return &RuntimeError{operator, "Must be a number"}
 	}
-
-	return aNum, bNum, nil
+	return nil
 }
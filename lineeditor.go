@@ -0,0 +1,440 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxHistory = 1000
+
+// errInterrupted is returned by ReadLine when the user abandons a line with Ctrl-C
+var errInterrupted = errors.New("interrupted")
+
+// LineReader reads lines of input, without their trailing newline, for the REPL
+type LineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+func NewLineReader(in io.Reader, out io.Writer) LineReader {
+	return &plainLineReader{bufio.NewReader(in), out}
+}
+
+// plainLineReader reads from anything that isn't an interactive terminal
+type plainLineReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *plainLineReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(p.out, prompt)
+	line, err := p.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// LineEditor reads lines from a terminal in raw mode, supporting cursor
+// movement, history that persists between sessions, reverse search with
+// Ctrl-R and tab completion
+func NewLineEditor(in *os.File, out io.Writer, historyPath string) *LineEditor {
+	editor := &LineEditor{
+		in:          in,
+		reader:      bufio.NewReader(in),
+		out:         out,
+		history:     make([]string, 0),
+		historyPath: historyPath,
+	}
+	editor.loadHistory()
+	return editor
+}
+
+var _ LineReader = &LineEditor{}
+
+type LineEditor struct {
+	in          *os.File
+	reader      *bufio.Reader
+	out         io.Writer
+	history     []string
+	historyPath string
+
+	// complete returns the candidates that could replace the word before
+	// the cursor
+	complete func(prefix string) []string
+}
+
+func (e *LineEditor) ReadLine(prompt string) (string, error) {
+	fd := int(e.in.Fd())
+	state, err := makeRaw(fd)
+	if err != nil {
+		return NewLineReader(e.reader, e.out).ReadLine(prompt)
+	}
+	defer restoreTerminal(fd, state)
+
+	return e.edit(prompt)
+}
+
+// Control keys
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyBackspace = 8
+	keyTab       = 9
+	keyLineFeed  = 10
+	keyCtrlK     = 11
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// lineState is the line being edited, with the cursor counted in runes
+type lineState struct {
+	prompt  string
+	buffer  []rune
+	cursor  int
+	history int
+	pending []rune
+}
+
+func (l *lineState) set(text string) {
+	l.buffer = []rune(text)
+	l.cursor = len(l.buffer)
+}
+
+func (l *lineState) insert(text []rune) {
+	buffer := make([]rune, 0, len(l.buffer)+len(text))
+	buffer = append(buffer, l.buffer[:l.cursor]...)
+	buffer = append(buffer, text...)
+	l.buffer = append(buffer, l.buffer[l.cursor:]...)
+	l.cursor += len(text)
+}
+
+func (l *lineState) remove(from int, to int) {
+	l.buffer = append(l.buffer[:from], l.buffer[to:]...)
+	l.cursor = from
+}
+
+// wordStart is the start of the identifier before the cursor
+func (l *lineState) wordStart() int {
+	start := l.cursor
+	for start > 0 && isWordRune(l.buffer[start-1]) {
+		start--
+	}
+	return start
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (e *LineEditor) edit(prompt string) (string, error) {
+	line := &lineState{prompt: prompt, history: len(e.history)}
+	e.refresh(line)
+
+	for {
+		key, _, err := e.reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		if key == keyCtrlR {
+			key, err = e.reverseSearch(line)
+			if err != nil {
+				return "", err
+			}
+			e.refresh(line)
+		}
+
+		switch key {
+		case keyEnter, keyLineFeed:
+			fmt.Fprint(e.out, "\r\n")
+			text := string(line.buffer)
+			e.addHistory(text)
+			return text, nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case keyCtrlD:
+			if len(line.buffer) == 0 {
+				return "", io.EOF
+			}
+			if line.cursor < len(line.buffer) {
+				line.remove(line.cursor, line.cursor+1)
+			}
+		case keyBackspace, keyDelete:
+			if line.cursor > 0 {
+				line.remove(line.cursor-1, line.cursor)
+			}
+		case keyCtrlA:
+			line.cursor = 0
+		case keyCtrlE:
+			line.cursor = len(line.buffer)
+		case keyCtrlB:
+			e.moveCursor(line, -1)
+		case keyCtrlF:
+			e.moveCursor(line, 1)
+		case keyCtrlK:
+			line.buffer = line.buffer[:line.cursor]
+		case keyCtrlU:
+			line.remove(0, line.cursor)
+		case keyCtrlW:
+			line.remove(line.wordStart(), line.cursor)
+		case keyCtrlP:
+			e.browseHistory(line, -1)
+		case keyCtrlN:
+			e.browseHistory(line, 1)
+		case keyTab:
+			e.completeWord(line)
+		case keyEscape:
+			e.escapeSequence(line)
+		default:
+			if unicode.IsPrint(key) {
+				line.insert([]rune{key})
+			}
+		}
+
+		e.refresh(line)
+	}
+}
+
+// escapeSequence handles the arrow, home, end and delete keys
+func (e *LineEditor) escapeSequence(line *lineState) {
+	introducer, _, err := e.reader.ReadRune()
+	if err != nil || (introducer != '[' && introducer != 'O') {
+		return
+	}
+
+	sequence := ""
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return
+		}
+		sequence += string(r)
+		if (r >= 'A' && r <= 'Z') || r == '~' {
+			break
+		}
+	}
+
+	switch sequence {
+	case "A":
+		e.browseHistory(line, -1)
+	case "B":
+		e.browseHistory(line, 1)
+	case "C":
+		e.moveCursor(line, 1)
+	case "D":
+		e.moveCursor(line, -1)
+	case "H", "1~", "7~":
+		line.cursor = 0
+	case "F", "4~", "8~":
+		line.cursor = len(line.buffer)
+	case "3~":
+		if line.cursor < len(line.buffer) {
+			line.remove(line.cursor, line.cursor+1)
+		}
+	}
+}
+
+func (e *LineEditor) moveCursor(line *lineState, by int) {
+	cursor := line.cursor + by
+	if cursor >= 0 && cursor <= len(line.buffer) {
+		line.cursor = cursor
+	}
+}
+
+// browseHistory steps through history, keeping what was being typed before
+// browsing began so it can be returned to
+func (e *LineEditor) browseHistory(line *lineState, by int) {
+	index := line.history + by
+	if index < 0 || index > len(e.history) {
+		return
+	}
+
+	if line.history == len(e.history) {
+		line.pending = append([]rune{}, line.buffer...)
+	}
+	line.history = index
+
+	if index == len(e.history) {
+		line.set(string(line.pending))
+	} else {
+		line.set(e.history[index])
+	}
+}
+
+// reverseSearch searches back through history for lines containing what's
+// typed, Ctrl-R moving to older matches. Any other key accepts the match and
+// is returned to be handled as usual, except Ctrl-G which cancels the search
+func (e *LineEditor) reverseSearch(line *lineState) (rune, error) {
+	original := string(line.buffer)
+	query := ""
+	match := len(e.history)
+	failed := false
+
+	search := func(from int) {
+		for i := from; i >= 0; i-- {
+			if i < len(e.history) && strings.Contains(e.history[i], query) {
+				match = i
+				failed = false
+				line.set(e.history[i])
+				return
+			}
+		}
+		failed = true
+	}
+
+	for {
+		status := "reverse-i-search"
+		if failed {
+			status = "failing " + status
+		}
+		fmt.Fprintf(e.out, "\r(%s)`%s': %s\x1b[K", status, query, string(line.buffer))
+
+		key, _, err := e.reader.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+
+		switch key {
+		case keyCtrlR:
+			if query != "" {
+				search(match - 1)
+			}
+		case keyBackspace, keyDelete:
+			if query != "" {
+				runes := []rune(query)
+				query = string(runes[:len(runes)-1])
+				search(len(e.history) - 1)
+			}
+		case keyCtrlG:
+			line.set(original)
+			return 0, nil
+		default:
+			if !unicode.IsPrint(key) {
+				line.history = match
+				return key, nil
+			}
+			query += string(key)
+			search(match)
+		}
+	}
+}
+
+// completeWord completes the identifier before the cursor. A single
+// candidate is filled in, otherwise the shared prefix of all of them is, and
+// if that doesn't add anything the candidates are listed
+func (e *LineEditor) completeWord(line *lineState) {
+	if e.complete == nil {
+		return
+	}
+
+	start := line.wordStart()
+	prefix := string(line.buffer[start:line.cursor])
+	if prefix == "" {
+		return
+	}
+
+	candidates := make([]string, 0)
+	for _, candidate := range e.complete(prefix) {
+		if strings.HasPrefix(candidate, prefix) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		fmt.Fprint(e.out, "\a")
+	case 1:
+		line.insert([]rune(strings.TrimPrefix(candidates[0], prefix)))
+	default:
+		common := commonPrefix(candidates)
+		if len(common) > len(prefix) {
+			line.insert([]rune(strings.TrimPrefix(common, prefix)))
+			return
+		}
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+// commonPrefix is the longest run of whole characters the words start with
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+// refresh redraws the line and puts the cursor back in place
+func (e *LineEditor) refresh(line *lineState) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", line.prompt, string(line.buffer))
+	if back := len(line.buffer) - line.cursor; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+// History
+
+func (e *LineEditor) loadHistory() {
+	if e.historyPath == "" {
+		return
+	}
+
+	content, err := os.ReadFile(e.historyPath)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+		os.WriteFile(e.historyPath, []byte(strings.Join(e.history, "\n")+"\n"), 0600)
+	}
+}
+
+func (e *LineEditor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[1:]
+	}
+
+	if e.historyPath == "" {
+		return
+	}
+	file, err := os.OpenFile(e.historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestEditor(input string, history ...string) *LineEditor {
+	return &LineEditor{
+		reader:  bufio.NewReader(strings.NewReader(input)),
+		out:     io.Discard,
+		history: history,
+	}
+}
+
+func TestLineEditorEditing(t *testing.T) {
+	cases := map[string]string{
+		"plain":             "print 1;\r",
+		"backspace":         "print 12\x7f;\r",
+		"left arrow":        "prin 1;\x1b[D\x1b[D\x1b[Dt\r",
+		"home and end":      "rint 1\x1b[Hp\x1b[F;\r",
+		"ctrl-a and ctrl-e": "rint 1\x01p\x05;\r",
+		"delete key":        "pXrint 1;\x01\x1b[C\x1b[3~\r",
+		"ctrl-u":            "junk\x15print 1;\r",
+		"ctrl-w":            "print junk\x171;\r",
+		"ctrl-k":            "print 1;junk\x1b[D\x1b[D\x1b[D\x1b[D\x0b\r",
+	}
+
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			line, err := newTestEditor(input).edit("> ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line != "print 1;" {
+				t.Errorf("expected %q, got %q", "print 1;", line)
+			}
+		})
+	}
+}
+
+func TestLineEditorInterruptAndEOF(t *testing.T) {
+	if _, err := newTestEditor("abc\x03").edit("> "); err != errInterrupted {
+		t.Errorf("expected Ctrl-C to interrupt, got %v", err)
+	}
+	if _, err := newTestEditor("\x04").edit("> "); err != io.EOF {
+		t.Errorf("expected Ctrl-D on an empty line to be EOF, got %v", err)
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	editor := newTestEditor("\x1b[A\x1b[A\r", "first", "second")
+	if line, _ := editor.edit("> "); line != "first" {
+		t.Errorf("expected two presses of up to recall %q, got %q", "first", line)
+	}
+
+	editor = newTestEditor("draft\x1b[A\x1b[B\r", "first")
+	if line, _ := editor.edit("> "); line != "draft" {
+		t.Errorf("expected down to return to the draft, got %q", line)
+	}
+
+	editor = newTestEditor("\x12pri\x12\r", "print 1;", "var a;", "print 2;")
+	if line, _ := editor.edit("> "); line != "print 1;" {
+		t.Errorf("expected reverse search to find %q, got %q", "print 1;", line)
+	}
+
+	editor = newTestEditor("\x12var\x1b[D!\r", "var a;")
+	if line, _ := editor.edit("> "); line != "var a!;" {
+		t.Errorf("expected the search to be accepted for editing, got %q", line)
+	}
+
+	editor = newTestEditor("x\x12var\x07\r", "var a;")
+	if line, _ := editor.edit("> "); line != "x" {
+		t.Errorf("expected Ctrl-G to cancel the search, got %q", line)
+	}
+}
+
+func TestLineEditorPersistsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	editor := NewLineEditor(nil, io.Discard, path)
+	editor.reader = bufio.NewReader(strings.NewReader("new\r\r"))
+	editor.edit("> ")
+	editor.edit("> ")
+
+	if !reflect.DeepEqual(editor.history, []string{"old", "new"}) {
+		t.Errorf("unexpected history %v", editor.history)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != "old\nnew\n" {
+		t.Errorf("unexpected history file %q", content)
+	}
+}
+
+func TestLineEditorCompletion(t *testing.T) {
+	repl := NewRepl(nil, io.Discard)
+	repl.lox.Run("var counter = 1; var count = 2;")
+
+	cases := map[string]string{
+		"pri\t 1;\r":         "print 1;",
+		"print cou\ter;\r":   "print counter;",
+		"wh\t (false) 1;\r":  "while (false) 1;",
+		"print xyz\t;\r":     "print xyz;",
+		"print counter\t;\r": "print counter;",
+	}
+
+	for input, expected := range cases {
+		editor := newTestEditor(input)
+		editor.complete = repl.complete
+		line, err := editor.edit("> ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != expected {
+			t.Errorf("%q: expected %q, got %q", input, expected, line)
+		}
+	}
+}
+
+func TestLineEditorCompletesWholeCharacters(t *testing.T) {
+	// é and è share their first byte, which mustn't be completed on its own
+	candidates := []string{"café", "cafè", "cafés"}
+	cases := map[string]string{
+		"caf\t\r":  "caf",
+		"café\t\r": "café",
+		"cafè\t\r": "cafè",
+	}
+
+	for input, expected := range cases {
+		editor := newTestEditor(input)
+		editor.complete = func(prefix string) []string { return candidates }
+		line, err := editor.edit("> ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != expected {
+			t.Errorf("%q: expected %q, got %q", input, expected, line)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LoxList is an ordered, mutable collection of values. Lists are created
+// and manipulated by natives, and compare equal only to themselves
+type LoxList struct {
+	elements []interface{}
+}
+
+func NewLoxList(elements []interface{}) *LoxList {
+	return &LoxList{elements}
+}
+
+// String shows the list's elements. A list or map inside it that contains
+// itself is shown as [...] or {...} where it repeats
+func (l *LoxList) String() string {
+	return l.stringify(make(map[interface{}]bool))
+}
+
+// stringify shows the list, given the collections already being shown
+// around it
+func (l *LoxList) stringify(showing map[interface{}]bool) string {
+	if showing[l] {
+		return "[...]"
+	}
+	showing[l] = true
+	defer delete(showing, l)
+
+	parts := make([]string, len(l.elements))
+	for i, element := range l.elements {
+		parts[i] = stringifyElement(element, showing)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// stringifyElement quotes strings, so that they can be told apart from
+// other values inside a collection
+func stringifyElement(value interface{}, showing map[interface{}]bool) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case *LoxList:
+		return v.stringify(showing)
+	case *LoxMap:
+		return v.stringify(showing)
+	}
+	return stringify(value)
+}
@@ -1,7 +1,9 @@
 package main
 
-func NewLox() *Lox {
-	interpreter := NewInterpreter()
+// NewLox creates an interpreter with no access to the host beyond printing,
+// so it's safe for untrusted code unless options grant more
+func NewLox(options ...Option) *Lox {
+	interpreter := NewInterpreter(options...)
 	return &Lox{
 		NewParser(),
 		interpreter,
@@ -14,17 +16,47 @@ type Lox struct {
 }
 
 func (l *Lox) Run(source string) error {
-	scanner := NewScanner(source)
-	resolver := NewResolver(l.interpreter)
-	scanner.Scan()
-	tokens := scanner.tokens
+	ast, err := l.Parse(source)
+	if err != nil {
+		return err
+	}
+	return l.execute(ast)
+}
 
-	l.parser.Load(tokens)
-	ast, err := l.parser.Parse()
+// RunAST runs a syntax tree in the JSON form written by glox ast
+func (l *Lox) RunAST(data []byte) error {
+	ast, err := UnmarshalAST(data, l.parser)
 	if err != nil {
+		report(err, 0)
 		return err
 	}
+	return l.execute(ast)
+}
 
+func (l *Lox) execute(ast []Stmt) error {
+	resolver := NewResolver(l.interpreter)
 	resolver.Resolve(ast)
 	return l.interpreter.Interpret(ast)
 }
+
+func (l *Lox) Parse(source string) ([]Stmt, error) {
+	scanner := NewScanner(source)
+	scanner.Scan()
+	if scanner.containsError {
+		return []Stmt{}, scanner.errors[0]
+	}
+
+	l.parser.Load(scanner.tokens)
+	return l.parser.Parse()
+}
+
+func (l *Lox) ParseExpression(source string) (Expr, error) {
+	scanner := NewScanner(source)
+	scanner.Scan()
+	if scanner.containsError {
+		return nil, scanner.errors[0]
+	}
+
+	l.parser.Load(scanner.tokens)
+	return l.parser.ParseExpression()
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// The conformance suite runs every script in testdata through Lox.Run and
+// checks it against annotations in the script, in the same format as the
+// Crafting Interpreters test suite:
+//
+//	print 1 + 2; // expect: 3
+//	print -"a"; // expect runtime error: Operand must be a number
+//	print "\q"; // expect compile error: invalid escape sequence '\q'
+//
+// Errors are matched on both their message and the line they occur on, and a
+// script with a compile error mustn't run at all.
+
+var (
+	expectOutput       = regexp.MustCompile(`// expect: ?(.*)$`)
+	expectRuntimeError = regexp.MustCompile(`// expect runtime error: (.+)$`)
+	expectCompileError = regexp.MustCompile(`// expect compile error: (.+)$`)
+)
+
+type expectation struct {
+	output    []string
+	errorType string
+	err       string
+	errorLine int
+}
+
+func TestConformance(t *testing.T) {
+	err := filepath.WalkDir("testdata", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".lox" {
+			return nil
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(path), "testdata/"), ".lox")
+		t.Run(name, func(t *testing.T) {
+			runConformanceScript(t, path)
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runConformanceScript(t *testing.T, path string) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := parseExpectations(string(source))
+
+	var stdout strings.Builder
+	var runErr error
+	stderr := captureStderr(t, func() {
+		runErr = NewLox(WithOutput(&stdout)).Run(string(source))
+	})
+
+	output := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if stdout.Len() == 0 {
+		output = []string{}
+	}
+	if diff := diffLines(expected.output, output); diff != "" {
+		t.Errorf("output mismatch (-expected +actual):\n%s", diff)
+	}
+
+	if expected.err == "" {
+		if runErr != nil {
+			t.Errorf("unexpected error: %s\n%s", runErr, stderr)
+		}
+		return
+	}
+
+	loxErr, ok := runErr.(LoxError)
+	if !ok || (expected.errorType == "CompileError") != (loxErr.Type() == "CompileError") {
+		t.Fatalf("expected %s %q on line %d, got %v\n%s", expected.errorType, expected.err, expected.errorLine, runErr, stderr)
+	}
+	if loxErr.Error() != expected.err {
+		t.Errorf("expected %s %q, got %q", expected.errorType, expected.err, loxErr.Error())
+	}
+	if line := loxErr.Token().line; line != expected.errorLine {
+		t.Errorf("expected %s on line %d, got line %d", expected.errorType, expected.errorLine, line)
+	}
+}
+
+func parseExpectations(source string) expectation {
+	expected := expectation{output: []string{}}
+
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		if match := expectRuntimeError.FindStringSubmatch(text); match != nil {
+			expected.errorType, expected.err, expected.errorLine = "RuntimeError", match[1], line
+			continue
+		}
+		if match := expectCompileError.FindStringSubmatch(text); match != nil {
+			expected.errorType, expected.err, expected.errorLine = "CompileError", match[1], line
+			continue
+		}
+		if match := expectOutput.FindStringSubmatch(text); match != nil {
+			expected.output = append(expected.output, match[1])
+		}
+	}
+
+	return expected
+}
+
+// runWithOptions runs source in a fresh interpreter, returning what it printed
+func runWithOptions(t *testing.T, source string, options ...Option) (string, error) {
+	t.Helper()
+
+	var out strings.Builder
+	var err error
+	captureStderr(t, func() {
+		err = NewLox(append([]Option{WithOutput(&out)}, options...)...).Run(source)
+	})
+	return out.String(), err
+}
+
+// captureStderr redirects the process' stderr while fn runs, returning what
+// was reported to it
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := os.Stderr
+	os.Stderr = w
+
+	captured := make(chan string, 1)
+	go func() {
+		content, _ := io.ReadAll(r)
+		r.Close()
+		captured <- string(content)
+	}()
+
+	fn()
+	w.Close()
+	os.Stderr = original
+
+	return <-captured
+}
+
+func diffLines(expected, actual []string) string {
+	var diff strings.Builder
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		switch {
+		case i >= len(actual):
+			diff.WriteString("- " + expected[i] + "\n")
+		case i >= len(expected):
+			diff.WriteString("+ " + actual[i] + "\n")
+		case expected[i] != actual[i]:
+			diff.WriteString("- " + expected[i] + "\n")
+			diff.WriteString("+ " + actual[i] + "\n")
+		}
+	}
+	return diff.String()
+}
@@ -1,60 +1,196 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
+const usage = `Usage: glox [--output=file] [--ast] [script [arguments...]]
+       glox ast [--format=json|sexpr] script
+       glox tokens [--format=text|json] script
+`
+
 func main() {
-	args := os.Args
+	args := os.Args[1:]
+
+	if len(args) > 0 && args[0] == "ast" {
+		runAst(args[1:])
+		return
+	} else if len(args) > 0 && args[0] == "tokens" {
+		runTokens(args[1:])
+		return
+	}
+
+	flags := flag.NewFlagSet("glox", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	output := flags.String("output", "", "write what scripts print to a file instead of stdout")
+	ast := flags.Bool("ast", false, "run a syntax tree written by glox ast rather than a script")
+	flags.Parse(args)
+
+	options := hostAccess()
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			report(err, 0)
+			os.Exit(1)
+		}
+		options = append(options, WithOutput(file))
+	}
 
-	if len(args) > 2 {
-		fmt.Print("Usage: glox [script]\n")
-	} else if len(args) == 2 {
-		runFile(args[1])
+	if flags.NArg() >= 1 {
+		runFile(flags.Arg(0), flags.Args()[1:], *ast, options)
 	} else {
-		runPrompt()
+		runPrompt(options)
 	}
 }
 
-func runFile(path string) {
+func runFile(path string, args []string, ast bool, options []Option) {
 	content, err := ioutil.ReadFile(path)
 	if err != nil {
 		report(err, 0)
 		os.Exit(1)
 	}
 
-	lox := NewLox()
-	err = lox.Run(string(content))
+	options = append(options, WithStdin(os.Stdin), WithArgs(args))
+	lox := NewLox(options...)
+	if ast {
+		err = lox.RunAST(content)
+	} else {
+		err = lox.Run(string(content))
+	}
+	if exit, ok := err.(ExitError); ok {
+		os.Exit(exit.Code)
+	}
+	if _, ok := err.(CompileError); ok {
+		os.Exit(65)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
 }
 
-func runPrompt() {
-	lox := NewLox()
-	for {
-		fmt.Print("> ")
-		bio := bufio.NewReader(os.Stdin)
-		line, err := bio.ReadString('\n')
-		if err != nil || line == "" {
+func runAst(args []string) {
+	flags := flag.NewFlagSet("ast", flag.ExitOnError)
+	format := flags.String("format", "json", "output format, json or sexpr")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Print(usage)
+		os.Exit(1)
+	}
+
+	ast, err := NewLox().Parse(readScript(flags.Arg(0)))
+	if err != nil {
+		os.Exit(1)
+	}
+
+	switch *format {
+	case "json":
+		encoded, err := MarshalAST(ast)
+		if err != nil {
+			report(err, 0)
+			os.Exit(1)
+		}
+		fmt.Println(string(encoded))
+	case "sexpr":
+		fmt.Println(NewAstPrinter().Print(ast))
+	default:
+		report(fmt.Errorf("unknown format '%s'", *format), 0)
+		os.Exit(1)
+	}
+}
+
+func runTokens(args []string) {
+	flags := flag.NewFlagSet("tokens", flag.ExitOnError)
+	format := flags.String("format", "text", "output format, text or json")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Print(usage)
+		os.Exit(1)
+	}
+
+	scanner := NewScanner(readScript(flags.Arg(0)))
+	scanner.Scan()
+
+	switch *format {
+	case "text":
+		for _, token := range scanner.tokens {
+			fmt.Printf("%d:%d %s\n", token.line, token.column, token)
+		}
+	case "json":
+		encoded, err := MarshalTokens(scanner.tokens, scanner.errors)
+		if err != nil {
 			report(err, 0)
+			os.Exit(1)
 		}
-		lox.Run(line)
+		fmt.Println(string(encoded))
+	default:
+		report(fmt.Errorf("unknown format '%s'", *format), 0)
+		os.Exit(1)
+	}
+
+	if scanner.containsError {
+		os.Exit(1)
+	}
+}
+
+func readScript(path string) string {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		report(err, 0)
+		os.Exit(1)
+	}
+	return string(content)
+}
+
+func runPrompt(options []Option) {
+	if !isTerminal(int(os.Stdin.Fd())) {
+		os.Exit(NewRepl(NewLineReader(os.Stdin, os.Stdout), os.Stdout, options...).Run())
+	}
+
+	editor := NewLineEditor(os.Stdin, os.Stdout, historyPath())
+	repl := NewRepl(editor, os.Stdout, options...)
+	editor.complete = repl.complete
+	os.Exit(repl.Run())
+}
+
+// hostAccess is what scripts run from the command line may use: the files
+// beneath the working directory and the environment. The REPL reads stdin
+// itself, so only scripts run from files are given it
+func hostAccess() []Option {
+	return []Option{
+		WithFileSystem(NewDirFS("."), "."),
+		WithEnvironment(os.LookupEnv),
+	}
+}
+
+// historyPath is where REPL history is kept, or empty if there's no home
+// directory to keep it in
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
 	}
+	return filepath.Join(home, ".glox_history")
 }
 
 func report(message error, line int) {
 	errorName := "Error"
+	column := 0
 	if loxError, ok := message.(LoxError); ok {
 		errorName = loxError.Type()
+		column = loxError.Token().column
 	}
 
-	if line != 0 {
-		fmt.Printf("[line %d] %s: %s\n", line, errorName, message.Error())
+	if line != 0 && column != 0 {
+		fmt.Fprintf(os.Stderr, "[line %d, column %d] %s: %s\n", line, column, errorName, message.Error())
+	} else if line != 0 {
+		fmt.Fprintf(os.Stderr, "[line %d] %s: %s\n", line, errorName, message.Error())
 	} else {
-		fmt.Printf("%s: %s\n", errorName, message.Error())
+		fmt.Fprintf(os.Stderr, "%s: %s\n", errorName, message.Error())
 	}
 }
@@ -0,0 +1,81 @@
+package main
+
+import "strings"
+
+// LoxMap associates keys with values, remembering the order keys were first
+// added in. Keys can be any value that compares equal by value: nil,
+// booleans, integers, floats and strings, but not big integers or decimals.
+// As 1 == 1.0, floats without a fractional part are stored as the equivalent
+// integer
+type LoxMap struct {
+	keys   []interface{}
+	values map[interface{}]interface{}
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{make([]interface{}, 0), make(map[interface{}]interface{})}
+}
+
+func (m *LoxMap) Get(key interface{}) (interface{}, bool) {
+	key = normaliseKey(key)
+	value, ok := m.values[key]
+	return value, ok
+}
+
+func (m *LoxMap) Set(key interface{}, value interface{}) {
+	key = normaliseKey(key)
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *LoxMap) Delete(key interface{}) {
+	key = normaliseKey(key)
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (m *LoxMap) String() string {
+	return m.stringify(make(map[interface{}]bool))
+}
+
+func (m *LoxMap) stringify(showing map[interface{}]bool) string {
+	if showing[m] {
+		return "{...}"
+	}
+	showing[m] = true
+	defer delete(showing, m)
+
+	parts := make([]string, len(m.keys))
+	for i, key := range m.keys {
+		parts[i] = stringifyElement(key, showing) + ": " + stringifyElement(m.values[key], showing)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func isHashable(value interface{}) bool {
+	switch value.(type) {
+	case nil, bool, int64, float64, string:
+		return true
+	}
+	return false
+}
+
+func normaliseKey(key interface{}) interface{} {
+	if f, ok := key.(float64); ok {
+		if whole, ok := wholeFloat(f); ok {
+			return whole
+		}
+	}
+	return key
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+func convertNatives() []*NativeFunction {
+	return []*NativeFunction{
+		{"num", 1, nativeNum},
+		{"str", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			return stringify(arguments[0]), nil
+		}},
+		{"type", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			return typeName(arguments[0]), nil
+		}},
+		{"format", variadic, nativeFormat},
+		{"int", 1, nativeInt},
+		{"float", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			return numberArgument(paren, "float", arguments, 0)
+		}},
+		{"isInteger", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			switch arguments[0].(type) {
+			case int64, *big.Int:
+				return true, nil
+			}
+			return false, nil
+		}},
+		{"bigint", 1, nativeBigint},
+		{"decimal", variadic, nativeDecimal},
+	}
+}
+
+// nativeNum parses strings as integers if they're written as one, and
+// otherwise as floats
+func nativeNum(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if isNumber(arguments[0]) {
+		return arguments[0], nil
+	}
+
+	str, err := stringArgument(paren, "num", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(str)
+	if integer, parseErr := strconv.ParseInt(text, 10, 64); parseErr == nil {
+		return integer, nil
+	}
+	number, parseErr := strconv.ParseFloat(text, 64)
+	if parseErr != nil {
+		return nil, RuntimeError{paren, fmt.Sprintf("Cannot convert %s to a number", strconv.Quote(str))}
+	}
+	return number, nil
+}
+
+// nativeInt truncates any other kind of number toward zero
+func nativeInt(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if !isNumber(arguments[0]) {
+		return nil, argumentError(paren, "int", 0, "number", arguments[0])
+	}
+	integer, ok := truncate(arguments[0])
+	if !ok || !integer.IsInt64() {
+		return nil, RuntimeError{paren, fmt.Sprintf("Cannot convert %s to an integer", stringify(arguments[0]))}
+	}
+	return integer.Int64(), nil
+}
+
+// nativeBigint truncates numbers toward zero, and parses strings of digits
+func nativeBigint(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if str, ok := arguments[0].(string); ok {
+		integer, ok := new(big.Int).SetString(strings.TrimSpace(str), 10)
+		if !ok {
+			return nil, RuntimeError{paren, fmt.Sprintf("Cannot convert %s to a big integer", strconv.Quote(str))}
+		}
+		return integer, nil
+	}
+
+	if !isNumber(arguments[0]) {
+		return nil, argumentError(paren, "bigint", 0, "number or string", arguments[0])
+	}
+	integer, ok := truncate(arguments[0])
+	if !ok {
+		return nil, RuntimeError{paren, fmt.Sprintf("Cannot convert %s to a big integer", stringify(arguments[0]))}
+	}
+	return integer, nil
+}
+
+// nativeDecimal converts a number or string to a decimal, rounding it to the
+// given number of places if there's a second argument. Floats convert to the
+// shortest decimal that reads back as the same float
+func nativeDecimal(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return nil, RuntimeError{paren, fmt.Sprintf("decimal expects 1 or 2 arguments, got %d", len(arguments))}
+	}
+
+	var value *Decimal
+	switch number := arguments[0].(type) {
+	case int64, *big.Int, *Decimal:
+		value = toDecimal(number)
+	case float64:
+		converted, ok := decimalFromFloat(number)
+		if !ok {
+			return nil, RuntimeError{paren, fmt.Sprintf("Cannot convert %s to a decimal", stringify(number))}
+		}
+		value = converted
+	case string:
+		converted, err := parseDecimal(strings.TrimSpace(number))
+		if err == errDecimalRange {
+			return nil, RuntimeError{paren, fmt.Sprintf("Cannot convert %s to a decimal, the %s", strconv.Quote(number), err)}
+		}
+		if err != nil {
+			return nil, RuntimeError{paren, fmt.Sprintf("Cannot convert %s to a decimal", strconv.Quote(number))}
+		}
+		value = converted
+	default:
+		return nil, argumentError(paren, "decimal", 0, "number or string", arguments[0])
+	}
+
+	if len(arguments) == 2 {
+		places, err := integerArgument(paren, "decimal", arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+		if places < 0 {
+			return nil, RuntimeError{paren, "Cannot round a decimal to a negative number of places"}
+		}
+		if places > maxDecimalExponent {
+			return nil, RuntimeError{paren, fmt.Sprintf("Cannot round a decimal to more than %d places", maxDecimalExponent)}
+		}
+		value = value.Round(places)
+	}
+	return value, nil
+}
+
+// nativeFormat is a printf-style formatter. Directives take the form
+// %[flags][width][.precision]verb, where flags are any of "-+0 " and the verbs
+// are:
+//
+//	s, v  any value, as print would show it
+//	d     a whole number
+//	x, X  a whole number in hexadecimal
+//	f     a number with a fixed number of decimal places, 6 by default, or
+//	      for decimals their own number of places
+//	e     a number in scientific notation
+//	g     a number in whichever of f or e is shorter
+//	%     a literal percent sign
+func nativeFormat(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if err := minArguments(paren, "format", arguments, 1); err != nil {
+		return nil, err
+	}
+	format, err := stringArgument(paren, "format", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var result strings.Builder
+	next := 1
+	runes := []rune(format)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' {
+			result.WriteRune(runes[i])
+			continue
+		}
+
+		start := i
+		i++
+		for i < len(runes) && strings.ContainsRune("-+0 123456789.", runes[i]) {
+			i++
+		}
+		if i >= len(runes) {
+			return nil, RuntimeError{paren, "Format string ends in the middle of a directive"}
+		}
+
+		spec, verb := string(runes[start:i]), runes[i]
+		if verb == '%' {
+			result.WriteRune('%')
+			continue
+		}
+
+		if next >= len(arguments) {
+			return nil, RuntimeError{paren, fmt.Sprintf("Format directive %s%c is missing an argument", spec, verb)}
+		}
+		formatted, err := formatDirective(paren, spec, verb, arguments, next)
+		if err != nil {
+			return nil, err
+		}
+		result.WriteString(formatted)
+		next++
+	}
+
+	if next < len(arguments) {
+		return nil, RuntimeError{paren, fmt.Sprintf("Format string uses %d arguments, got %d", next-1, len(arguments)-1)}
+	}
+	return result.String(), nil
+}
+
+func formatDirective(paren Token, spec string, verb rune, arguments []interface{}, index int) (string, LoxError) {
+	switch verb {
+	case 's', 'v':
+		return fmt.Sprintf(spec+"s", stringify(arguments[index])), nil
+	case 'd', 'x', 'X':
+		switch integer := arguments[index].(type) {
+		case int64, *big.Int:
+			return fmt.Sprintf(spec+string(verb), integer), nil
+		}
+		number, err := numberArgument(paren, "format", arguments, index)
+		if err != nil {
+			return "", err
+		}
+		integer, ok := wholeFloat(number)
+		if !ok {
+			return "", argumentError(paren, "format", index, "whole number", number)
+		}
+		return fmt.Sprintf(spec+string(verb), integer), nil
+	case 'f', 'e', 'g':
+		// Decimals are formatted exactly, without going through a float
+		switch exact := arguments[index].(type) {
+		case *big.Int:
+			if verb == 'f' {
+				return fmt.Sprintf(spec+"f", decimalFromInt(exact)), nil
+			}
+		case *Decimal:
+			if verb == 'f' {
+				return fmt.Sprintf(spec+"f", exact), nil
+			}
+		}
+		number, err := numberArgument(paren, "format", arguments, index)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(spec+string(verb), number), nil
+	}
+
+	return "", RuntimeError{paren, fmt.Sprintf("Unknown format directive %s%c", spec, verb)}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileSystem confines the file natives. Reads go through an fs.FS, which may
+// be a directory on disk or in memory, and writes go beneath a root directory
+type FileSystem struct {
+	read      fs.FS
+	writeRoot string
+}
+
+var errOutsideRoot = errors.New("outside of the accessible files")
+
+// dirFS reads the files beneath a directory on disk. Unlike os.DirFS it
+// won't follow symlinks out of the directory
+type dirFS string
+
+// NewDirFS gives access to the files beneath root
+func NewDirFS(root string) fs.FS {
+	return dirFS(root)
+}
+
+func (root dirFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	resolved, err := resolveBeneath(string(root), name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return os.Open(resolved)
+}
+
+func fileNatives() []*NativeFunction {
+	return []*NativeFunction{
+		{"readFile", 1, nativeReadFile},
+		{"writeFile", 2, fileWriter("writeFile", os.O_TRUNC)},
+		{"appendFile", 2, fileWriter("appendFile", os.O_APPEND)},
+		{"listDir", 1, nativeListDir},
+		{"exists", 1, nativeExists},
+		{"remove", 1, nativeRemove},
+	}
+}
+
+func nativeReadFile(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	files, name, err := readablePath(interpreter, paren, "readFile", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	content, readErr := fs.ReadFile(files.read, name)
+	if readErr != nil {
+		return nil, fileError(paren, "read", name, readErr)
+	}
+	return string(content), nil
+}
+
+func nativeListDir(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	files, name, err := readablePath(interpreter, paren, "listDir", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, readErr := fs.ReadDir(files.read, name)
+	if readErr != nil {
+		return nil, fileError(paren, "list", name, readErr)
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	sort.Strings(names)
+
+	elements := make([]interface{}, len(names))
+	for i, name := range names {
+		elements[i] = name
+	}
+	return NewLoxList(elements), nil
+}
+
+func nativeExists(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	files, name, err := readablePath(interpreter, paren, "exists", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	_, statErr := fs.Stat(files.read, name)
+	if errors.Is(statErr, fs.ErrNotExist) {
+		return false, nil
+	}
+	if statErr != nil {
+		return nil, fileError(paren, "check", name, statErr)
+	}
+	return true, nil
+}
+
+func nativeRemove(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	name, path, err := writablePath(interpreter, paren, "remove", "remove", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if removeErr := os.Remove(path); removeErr != nil {
+		return nil, fileError(paren, "remove", name, removeErr)
+	}
+	return nil, nil
+}
+
+func fileWriter(nativeName string, mode int) func(*Interpreter, Token, []interface{}) (interface{}, LoxError) {
+	return func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+		name, path, err := writablePath(interpreter, paren, nativeName, "write", arguments)
+		if err != nil {
+			return nil, err
+		}
+		content, err := stringArgument(paren, nativeName, arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		file, openErr := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|mode, 0644)
+		if openErr != nil {
+			return nil, fileError(paren, "write", name, openErr)
+		}
+		defer file.Close()
+
+		if _, writeErr := file.WriteString(content); writeErr != nil {
+			return nil, fileError(paren, "write", name, writeErr)
+		}
+		return nil, nil
+	}
+}
+
+func readablePath(interpreter *Interpreter, paren Token, nativeName string, arguments []interface{}) (*FileSystem, string, LoxError) {
+	if interpreter.files == nil || interpreter.files.read == nil {
+		return nil, "", RuntimeError{paren, "File access is disabled"}
+	}
+	name, err := sandboxedPath(paren, nativeName, arguments)
+	return interpreter.files, name, err
+}
+
+// writablePath returns both the script's name for a file and where it is on
+// disk, which mustn't be the root itself
+func writablePath(interpreter *Interpreter, paren Token, nativeName string, action string, arguments []interface{}) (string, string, LoxError) {
+	if interpreter.files == nil || interpreter.files.writeRoot == "" {
+		return "", "", RuntimeError{paren, "Writing files is disabled"}
+	}
+	name, err := sandboxedPath(paren, nativeName, arguments)
+	if err != nil {
+		return "", "", err
+	}
+	if name == "." {
+		return "", "", RuntimeError{paren, fmt.Sprintf("Cannot %s the root of the accessible files", action)}
+	}
+
+	resolved, resolveErr := resolveBeneath(interpreter.files.writeRoot, name)
+	if resolveErr != nil {
+		return "", "", fileError(paren, action, name, resolveErr)
+	}
+	return name, resolved, nil
+}
+
+// resolveBeneath finds where name is on disk once any symlinks are followed,
+// failing if that's outside root. The file needn't exist, but its directory
+// must
+func resolveBeneath(root string, name string) (string, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	full := filepath.Join(realRoot, filepath.FromSlash(name))
+
+	resolved, err := filepath.EvalSymlinks(full)
+	if errors.Is(err, fs.ErrNotExist) {
+		// A symlink to nowhere would be followed when the file is created
+		if _, lstatErr := os.Lstat(full); lstatErr == nil {
+			return "", errOutsideRoot
+		}
+		dir, dirErr := filepath.EvalSymlinks(filepath.Dir(full))
+		if dirErr != nil {
+			return "", dirErr
+		}
+		resolved = filepath.Join(dir, filepath.Base(full))
+	} else if err != nil {
+		return "", err
+	}
+
+	relative, err := filepath.Rel(realRoot, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", errOutsideRoot
+	}
+	return resolved, nil
+}
+
+// sandboxedPath only accepts slash separated paths relative to the root of
+// the file system, which can't climb out of it
+func sandboxedPath(paren Token, nativeName string, arguments []interface{}) (string, LoxError) {
+	name, err := stringArgument(paren, nativeName, arguments, 0)
+	if err != nil {
+		return "", err
+	}
+
+	cleaned := path.Clean(name)
+	if !fs.ValidPath(cleaned) {
+		return "", RuntimeError{paren, fmt.Sprintf("Path '%s' is %s", name, errOutsideRoot)}
+	}
+	return cleaned, nil
+}
+
+// fileError reports the same message for common errors whichever file system
+// they came from
+func fileError(paren Token, action string, name string, err error) LoxError {
+	var pathErr *fs.PathError
+	switch {
+	case errors.Is(err, errOutsideRoot):
+		return RuntimeError{paren, fmt.Sprintf("Path '%s' is %s", name, errOutsideRoot)}
+	case errors.Is(err, fs.ErrNotExist):
+		err = fs.ErrNotExist
+	case errors.Is(err, fs.ErrPermission):
+		err = fs.ErrPermission
+	case errors.As(err, &pathErr):
+		err = pathErr.Err
+	}
+	return RuntimeError{paren, fmt.Sprintf("Cannot %s '%s': %s", action, name, err)}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFileNativesReadFromFS(t *testing.T) {
+	files := fstest.MapFS{
+		"notes.txt":     {Data: []byte("remember the milk")},
+		"docs/a.md":     {Data: []byte("a")},
+		"docs/b.md":     {Data: []byte("b")},
+		"docs/sub/c.md": {Data: []byte("c")},
+	}
+
+	output, err := runWithOptions(t, `
+		print readFile("notes.txt");
+		print readFile("./docs/../notes.txt");
+		print listDir("docs");
+		print exists("docs/a.md");
+		print exists("docs/z.md");
+	`, WithFileSystem(files, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "remember the milk\nremember the milk\n[\"a.md\", \"b.md\", \"sub\"]\ntrue\nfalse\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestFileNativesWriteBeneathRoot(t *testing.T) {
+	root := t.TempDir()
+
+	output, err := runWithOptions(t, `
+		writeFile("log.txt", "one");
+		appendFile("log.txt", ",two");
+		print readFile("log.txt");
+		writeFile("log.txt", "three");
+		print readFile("log.txt");
+		remove("log.txt");
+		print exists("log.txt");
+	`, WithFileSystem(NewDirFS(root), root))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "one,two\nthree\nfalse\n"; output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestFileNativesStayInsideRoot(t *testing.T) {
+	outer := t.TempDir()
+	root := filepath.Join(outer, "sandbox")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outer, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scripts := map[string]string{
+		`readFile("../secret.txt");`:       "Path '../secret.txt' is outside of the accessible files",
+		`readFile("/etc/passwd");`:         "Path '/etc/passwd' is outside of the accessible files",
+		`writeFile("../escape.txt", "x");`: "Path '../escape.txt' is outside of the accessible files",
+		`remove("../secret.txt");`:         "Path '../secret.txt' is outside of the accessible files",
+		`readFile("missing.txt");`:         "Cannot read 'missing.txt': file does not exist",
+	}
+
+	for script, message := range scripts {
+		_, err := runWithOptions(t, script, WithFileSystem(NewDirFS(root), root))
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected error %q, got %v", script, message, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(outer, "secret.txt")); err != nil {
+		t.Errorf("file outside the root was touched: %s", err)
+	}
+}
+
+func TestFileNativesDontFollowSymlinksOut(t *testing.T) {
+	outer := t.TempDir()
+	root := filepath.Join(outer, "sandbox")
+	if err := os.MkdirAll(filepath.Join(root, "inner"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outer, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"out":          outer,
+		"secret.txt":   filepath.Join(outer, "secret.txt"),
+		"dangling.txt": filepath.Join(outer, "created.txt"),
+		"inside":       filepath.Join(root, "inner"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skipf("can't create symlinks: %s", err)
+		}
+	}
+
+	scripts := map[string]string{
+		`readFile("secret.txt");`:            "Path 'secret.txt' is outside of the accessible files",
+		`readFile("out/secret.txt");`:        "Path 'out/secret.txt' is outside of the accessible files",
+		`listDir("out");`:                    "Path 'out' is outside of the accessible files",
+		`writeFile("secret.txt", "x");`:      "Path 'secret.txt' is outside of the accessible files",
+		`appendFile("out/secret.txt", "x");`: "Path 'out/secret.txt' is outside of the accessible files",
+		`writeFile("out/new.txt", "x");`:     "Path 'out/new.txt' is outside of the accessible files",
+		`writeFile("dangling.txt", "x");`:    "Path 'dangling.txt' is outside of the accessible files",
+		`remove("out/secret.txt");`:          "Path 'out/secret.txt' is outside of the accessible files",
+		`remove("");`:                        "Cannot remove the root of the accessible files",
+		`remove(".");`:                       "Cannot remove the root of the accessible files",
+		`writeFile("inner/..", "x");`:        "Cannot write the root of the accessible files",
+	}
+	for script, message := range scripts {
+		_, err := runWithOptions(t, script, WithFileSystem(NewDirFS(root), root))
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected error %q, got %v", script, message, err)
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(outer, "secret.txt")); err != nil || string(content) != "secret" {
+		t.Errorf("file outside the root was touched: %q %v", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(outer, "created.txt")); err == nil {
+		t.Error("file was created outside the root")
+	}
+
+	// Symlinks that stay inside the root work as usual
+	output, err := runWithOptions(t, `
+		writeFile("inside/note.txt", "kept");
+		print readFile("inner/note.txt");
+		print listDir("inside");
+	`, WithFileSystem(NewDirFS(root), root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "kept\n[\"note.txt\"]\n"; output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
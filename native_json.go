@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// JSON objects become maps that keep the order of their keys, arrays become
+// lists, and numbers become integers unless they have a fraction or exponent
+
+func jsonNatives() []*NativeFunction {
+	return []*NativeFunction{
+		{"jsonParse", 1, nativeJSONParse},
+		{"jsonStringify", variadic, nativeJSONStringify},
+	}
+}
+
+func nativeJSONParse(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	source, err := stringArgument(paren, "jsonParse", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validating first gives errors an offset, which the token stream
+	// doesn't always have
+	var discard interface{}
+	if err := json.Unmarshal([]byte(source), &discard); err != nil {
+		return nil, jsonParseError(paren, err)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(source))
+	decoder.UseNumber()
+	value, decodeErr := decodeJSONValue(decoder)
+	if decodeErr != nil {
+		return nil, jsonParseError(paren, decodeErr)
+	}
+	return value, nil
+}
+
+func jsonParseError(paren Token, err error) LoxError {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return RuntimeError{paren, fmt.Sprintf("Invalid JSON at offset %d: %s", syntaxErr.Offset, syntaxErr)}
+	}
+	return RuntimeError{paren, "Invalid JSON: " + err.Error()}
+}
+
+func decodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if number, ok := token.(json.Number); ok {
+		return jsonNumber(number)
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '[':
+		elements := make([]interface{}, 0)
+		for decoder.More() {
+			element, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		_, err := decoder.Token()
+		return NewLoxList(elements), err
+	case '{':
+		object := NewLoxMap()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.Set(key, value)
+		}
+		_, err := decoder.Token()
+		return object, err
+	}
+
+	return nil, fmt.Errorf("unexpected %s", delim)
+}
+
+// jsonNumber is an integer if it's written as one and fits, otherwise a float
+func jsonNumber(number json.Number) (interface{}, error) {
+	if !strings.ContainsAny(string(number), ".eE") {
+		if integer, err := number.Int64(); err == nil {
+			return integer, nil
+		}
+	}
+	return number.Float64()
+}
+
+// nativeJSONStringify encodes a value, indenting by the given number of
+// spaces or string if there's a second argument
+func nativeJSONStringify(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return nil, RuntimeError{paren, fmt.Sprintf("jsonStringify expects 1 or 2 arguments, got %d", len(arguments))}
+	}
+
+	indent := ""
+	if len(arguments) == 2 {
+		switch value := arguments[1].(type) {
+		case nil:
+		case string:
+			indent = value
+		default:
+			spaces, err := integerArgument(paren, "jsonStringify", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			if spaces < 0 {
+				return nil, RuntimeError{paren, "Cannot indent JSON by a negative number of spaces"}
+			}
+			if spaces > maxStringLength {
+				return nil, tooLong(paren, "jsonStringify")
+			}
+			indent = strings.Repeat(" ", spaces)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := encodeJSONValue(&buffer, arguments[0], paren, make(map[interface{}]bool)); err != nil {
+		return nil, err
+	}
+	if indent == "" {
+		return buffer.String(), nil
+	}
+
+	var indented bytes.Buffer
+	json.Indent(&indented, buffer.Bytes(), "", indent)
+	return indented.String(), nil
+}
+
+// encodeJSONValue writes value to buffer, given the lists and maps being
+// encoded around it, as one that contains itself can't be encoded
+func encodeJSONValue(buffer *bytes.Buffer, value interface{}, paren Token, encoding map[interface{}]bool) LoxError {
+	switch value.(type) {
+	case *LoxList, *LoxMap:
+		if encoding[value] {
+			return RuntimeError{paren, fmt.Sprintf("Cannot convert a %s that contains itself to JSON", typeName(value))}
+		}
+		encoding[value] = true
+		defer delete(encoding, value)
+	}
+
+	switch v := value.(type) {
+	case nil:
+		buffer.WriteString("null")
+	case bool:
+		encoded, _ := json.Marshal(v)
+		buffer.Write(encoded)
+	case string:
+		writeJSONString(buffer, v)
+	case int64:
+		buffer.WriteString(formatInteger(v))
+	case *big.Int, *Decimal:
+		buffer.WriteString(stringify(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return RuntimeError{paren, fmt.Sprintf("Cannot convert %s to JSON", stringify(v))}
+		}
+		encoded, _ := json.Marshal(v)
+		buffer.Write(encoded)
+	case *LoxList:
+		buffer.WriteByte('[')
+		for i, element := range v.elements {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := encodeJSONValue(buffer, element, paren, encoding); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(']')
+	case *LoxMap:
+		buffer.WriteByte('{')
+		for i, key := range v.keys {
+			str, ok := key.(string)
+			if !ok {
+				return RuntimeError{paren, fmt.Sprintf("Cannot convert map with %s key %s to JSON", typeName(key), stringify(key))}
+			}
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeJSONString(buffer, str)
+			buffer.WriteByte(':')
+			if err := encodeJSONValue(buffer, v.values[key], paren, encoding); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte('}')
+	default:
+		return RuntimeError{paren, fmt.Sprintf("Cannot convert %s to JSON", typeName(value))}
+	}
+	return nil
+}
+
+// writeJSONString quotes str for JSON, leaving alone the characters that are
+// only special in HTML
+func writeJSONString(buffer *bytes.Buffer, str string) {
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(str)
+	buffer.Truncate(buffer.Len() - 1)
+}
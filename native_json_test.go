@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestJSONParse(t *testing.T) {
+	output, err := runWithOptions(t, `
+		var config = jsonParse(r"""{"name": "glox", "version": 2, "tags": ["a", true, null], "nested": {"x": 1.5, "quote": "say \"hi\""}}""");
+		print config;
+		print get(config, "version") + 1;
+		print get(get(config, "tags"), 0);
+		print get(get(config, "nested"), "quote");
+		print keys(config);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		`{"name": "glox", "version": 2, "tags": ["a", true, nil], "nested": {"x": 1.5, "quote": "say \"hi\""}}`,
+		`3`,
+		`a`,
+		`say "hi"`,
+		`["name", "version", "tags", "nested"]`,
+		``,
+	}, "\n")
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestJSONParseScalars(t *testing.T) {
+	output, err := runWithOptions(t, `
+		print jsonParse("[]");
+		print jsonParse("null");
+		print jsonParse(" 42 ");
+		print jsonParse("\"text\"");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "[]\nnil\n42\ntext\n"; output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestJSONParseNumbers(t *testing.T) {
+	output, err := runWithOptions(t, `
+		var numbers = jsonParse("[9007199254740993, 1.0, 1e2, -7, 18446744073709551616]");
+		for (var i = 0; i < len(numbers); i = i + 1) {
+			print format("%v %s", get(numbers, i), isInteger(get(numbers, i)));
+		}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "9007199254740993 true\n1 false\n100 false\n-7 true\n18446744073709552000 false\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestJSONParseErrors(t *testing.T) {
+	cases := map[string]string{
+		`{"a": 1,}`: "Invalid JSON at offset 9: invalid character '}' looking for beginning of object key string",
+		`[1, 2`:     "Invalid JSON at offset 5: unexpected end of JSON input",
+		`[1] [2]`:   "Invalid JSON at offset 5: invalid character '[' after top-level value",
+		``:          "Invalid JSON at offset 0: unexpected end of JSON input",
+	}
+
+	for input, message := range cases {
+		_, err := runWithOptions(t, "jsonParse("+strconv.Quote(input)+");")
+		if err == nil || err.Error() != message {
+			t.Errorf("%q: expected error %q, got %v", input, message, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import "fmt"
+
+func listNatives() []*NativeFunction {
+	return []*NativeFunction{
+		{"list", variadic, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			return NewLoxList(append([]interface{}{}, arguments...)), nil
+		}},
+		{"push", 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			list, err := listArgument(paren, "push", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			list.elements = append(list.elements, arguments[1])
+			return nil, nil
+		}},
+		{"pop", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			list, err := listArgument(paren, "pop", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			if len(list.elements) == 0 {
+				return nil, RuntimeError{paren, "Cannot pop from an empty list"}
+			}
+			last := list.elements[len(list.elements)-1]
+			list.elements = list.elements[:len(list.elements)-1]
+			return last, nil
+		}},
+		{"get", 2, nativeGet},
+		{"set", 3, nativeSet},
+	}
+}
+
+// get and set work on both lists and maps
+
+func nativeGet(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if m, ok := arguments[0].(*LoxMap); ok {
+		key, err := mapKey(paren, "get", arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+		value, _ := m.Get(key)
+		return value, nil
+	}
+
+	list, index, err := listIndex(paren, "get", arguments)
+	if err != nil {
+		return nil, err
+	}
+	return list.elements[index], nil
+}
+
+func nativeSet(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if m, ok := arguments[0].(*LoxMap); ok {
+		key, err := mapKey(paren, "set", arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+		m.Set(key, arguments[2])
+		return nil, nil
+	}
+
+	list, index, err := listIndex(paren, "set", arguments)
+	if err != nil {
+		return nil, err
+	}
+	list.elements[index] = arguments[2]
+	return nil, nil
+}
+
+func listIndex(paren Token, name string, arguments []interface{}) (*LoxList, int, LoxError) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, 0, argumentError(paren, name, 0, "list or map", arguments[0])
+	}
+	index, err := integerArgument(paren, name, arguments, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if index < 0 || index >= len(list.elements) {
+		return nil, 0, RuntimeError{paren, fmt.Sprintf("Index %d is out of range for a list of length %d", index, len(list.elements))}
+	}
+	return list, index, nil
+}
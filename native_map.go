@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+func mapNatives() []*NativeFunction {
+	return []*NativeFunction{
+		{"map", variadic, nativeMap},
+		{"has", 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			m, err := mapArgument(paren, "has", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			_, ok := m.Get(arguments[1])
+			return ok, nil
+		}},
+		{"keys", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			m, err := mapArgument(paren, "keys", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return NewLoxList(append([]interface{}{}, m.keys...)), nil
+		}},
+		{"delete", 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			m, err := mapArgument(paren, "delete", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			m.Delete(arguments[1])
+			return nil, nil
+		}},
+	}
+}
+
+// nativeMap creates a map from alternating keys and values
+func nativeMap(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if len(arguments)%2 != 0 {
+		return nil, RuntimeError{paren, "map expects alternating keys and values, got an odd number of arguments"}
+	}
+
+	m := NewLoxMap()
+	for i := 0; i < len(arguments); i += 2 {
+		key, err := mapKey(paren, "map", arguments, i)
+		if err != nil {
+			return nil, err
+		}
+		m.Set(key, arguments[i+1])
+	}
+	return m, nil
+}
+
+func mapArgument(paren Token, name string, arguments []interface{}, index int) (*LoxMap, LoxError) {
+	m, ok := arguments[index].(*LoxMap)
+	if !ok {
+		return nil, argumentError(paren, name, index, "map", arguments[index])
+	}
+	return m, nil
+}
+
+// mapKey names the kind of number a key is when it's rejected, as only some
+// kinds can be keys
+func mapKey(paren Token, name string, arguments []interface{}, index int) (interface{}, LoxError) {
+	if !isHashable(arguments[index]) {
+		kind := typeName(arguments[index])
+		switch arguments[index].(type) {
+		case *big.Int:
+			kind = "big integer"
+		case *Decimal:
+			kind = "decimal"
+		}
+		return nil, RuntimeError{paren, fmt.Sprintf("Cannot use a %s as a map key", kind)}
+	}
+	return arguments[index], nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"math"
+	"math/big"
+)
+
+func mathConstants() map[string]interface{} {
+	return map[string]interface{}{
+		"pi":  math.Pi,
+		"e":   math.E,
+		"inf": math.Inf(1),
+		"nan": math.NaN(),
+	}
+}
+
+func mathNatives() []*NativeFunction {
+	return []*NativeFunction{
+		unaryMath("sqrt", math.Sqrt),
+		{"abs", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			if integer, ok := arguments[0].(int64); ok {
+				if integer < 0 {
+					return negate(paren, integer)
+				}
+				return integer, nil
+			}
+			switch exact := arguments[0].(type) {
+			case *big.Int:
+				return new(big.Int).Abs(exact), nil
+			case *Decimal:
+				if exact.Sign() < 0 {
+					return exact.Neg(), nil
+				}
+				return exact, nil
+			}
+			x, err := numberArgument(paren, "abs", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return math.Abs(x), nil
+		}},
+		roundingMath("floor", math.Floor, (*Decimal).Floor),
+		roundingMath("ceil", math.Ceil, (*Decimal).Ceil),
+		roundingMath("round", math.Round, func(d *Decimal) *Decimal { return d.Round(0) }),
+		roundingMath("trunc", math.Trunc, (*Decimal).Trunc),
+		unaryMath("sin", math.Sin),
+		unaryMath("cos", math.Cos),
+		unaryMath("tan", math.Tan),
+		unaryMath("log", math.Log),
+		unaryMath("exp", math.Exp),
+		binaryMath("pow", math.Pow),
+		binaryMath("atan2", math.Atan2),
+		extremum("min", -1),
+		extremum("max", 1),
+		{"isNaN", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			x, err := numberArgument(paren, "isNaN", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return math.IsNaN(x), nil
+		}},
+		{"isFinite", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			x, err := numberArgument(paren, "isFinite", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return !math.IsNaN(x) && !math.IsInf(x, 0), nil
+		}},
+	}
+}
+
+func unaryMath(name string, fn func(float64) float64) *NativeFunction {
+	return &NativeFunction{name, 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+		x, err := numberArgument(paren, name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(x), nil
+	}}
+}
+
+// roundingMath rounds floats with fn and decimals exactly with exact, leaving
+// integers as they are
+func roundingMath(name string, fn func(float64) float64, exact func(*Decimal) *Decimal) *NativeFunction {
+	return &NativeFunction{name, 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+		switch x := arguments[0].(type) {
+		case int64, *big.Int:
+			return x, nil
+		case *Decimal:
+			return exact(x), nil
+		}
+		x, err := numberArgument(paren, name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(x), nil
+	}}
+}
+
+func binaryMath(name string, fn func(float64, float64) float64) *NativeFunction {
+	return &NativeFunction{name, 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+		x, err := numberArgument(paren, name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		y, err := numberArgument(paren, name, arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+		return fn(x, y), nil
+	}}
+}
+
+// extremum picks one of one or more numbers, the least for an order of -1
+// and the greatest for 1, comparing them exactly and returning the one chosen
+// as it was given. NaN wins over any number
+func extremum(name string, order int) *NativeFunction {
+	return &NativeFunction{name, variadic, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+		if err := minArguments(paren, name, arguments, 1); err != nil {
+			return nil, err
+		}
+
+		result := arguments[0]
+		for i, x := range arguments {
+			if !isNumber(x) {
+				return nil, argumentError(paren, name, i, "number", x)
+			}
+			comparison, ok := compareValues(x, result)
+			if ok && comparison == order || !ok && !isNaN(result) {
+				result = x
+			}
+		}
+		return result, nil
+	}}
+}
+
+func isNaN(value interface{}) bool {
+	f, ok := value.(float64)
+	return ok && math.IsNaN(f)
+}
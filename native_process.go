@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// Process natives read from the host's stdin, arguments and environment,
+// each of which is empty unless the host provides it
+
+func processNatives() []*NativeFunction {
+	return []*NativeFunction{
+		{"readLine", 0, nativeReadLine},
+		{"readAll", 0, nativeReadAll},
+		{"args", 0, nativeArgs},
+		{"env", 1, nativeEnv},
+		{"exit", 1, nativeExit},
+	}
+}
+
+// nativeReadLine returns the next line without its line ending, or nil once
+// the input is exhausted
+func nativeReadLine(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if interpreter.stdin == nil {
+		return nil, nil
+	}
+
+	line, err := interpreter.stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, RuntimeError{paren, "Cannot read input: " + err.Error()}
+	}
+	if err == io.EOF && line == "" {
+		return nil, nil
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func nativeReadAll(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	if interpreter.stdin == nil {
+		return "", nil
+	}
+
+	content, err := io.ReadAll(interpreter.stdin)
+	if err != nil {
+		return nil, RuntimeError{paren, "Cannot read input: " + err.Error()}
+	}
+	return string(content), nil
+}
+
+func nativeArgs(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	elements := make([]interface{}, len(interpreter.args))
+	for i, arg := range interpreter.args {
+		elements[i] = arg
+	}
+	return NewLoxList(elements), nil
+}
+
+// nativeEnv returns nil for variables that aren't set
+func nativeEnv(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	name, err := stringArgument(paren, "env", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if interpreter.env == nil {
+		return nil, nil
+	}
+
+	value, ok := interpreter.env(name)
+	if !ok {
+		return nil, nil
+	}
+	return value, nil
+}
+
+func nativeExit(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	code, err := integerArgument(paren, "exit", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return nil, ExitError{code, paren}
+}
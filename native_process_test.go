@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestProcessNatives(t *testing.T) {
+	environment := map[string]string{"GREETING": "hello"}
+	lookup := func(name string) (string, bool) {
+		value, ok := environment[name]
+		return value, ok
+	}
+
+	output, err := runWithOptions(t, `
+		print readLine();
+		print readLine();
+		print readAll();
+		print readLine();
+		print args();
+		print env("GREETING");
+		print env("MISSING");
+	`,
+		WithStdin(strings.NewReader("first\r\nsecond\nthe\nrest")),
+		WithArgs([]string{"-v", "input.txt"}),
+		WithEnvironment(lookup),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "first\nsecond\nthe\nrest\nnil\n[\"-v\", \"input.txt\"]\nhello\nnil\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestExitUnwindsQuietly(t *testing.T) {
+	var stdout strings.Builder
+	var err error
+	stderr := captureStderr(t, func() {
+		err = NewLox(WithOutput(&stdout)).Run(`
+			fun check(n) {
+				while (true) {
+					if (n > 1) exit(n);
+					return;
+				}
+			}
+			print "before";
+			check(3);
+			print "after";
+		`)
+	})
+
+	exit, ok := err.(ExitError)
+	if !ok || exit.Code != 3 {
+		t.Fatalf("expected exit with status 3, got %v", err)
+	}
+	if stdout.String() != "before\n" {
+		t.Errorf("expected execution to stop at exit, got %q", stdout.String())
+	}
+	if stderr != "" {
+		t.Errorf("expected exit not to be reported, got %q", stderr)
+	}
+}
+
+func TestReplExit(t *testing.T) {
+	repl := NewRepl(NewLineReader(strings.NewReader("exit(4);\nprint 1;\n"), io.Discard), io.Discard)
+	code := repl.Run()
+
+	if code != 4 {
+		t.Errorf("expected the REPL to exit with status 4, got %d", code)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// The random natives share the interpreter's source, so seeding it with seed
+// or WithSeed makes everything they return reproducible
+
+func randomNatives() []*NativeFunction {
+	return []*NativeFunction{
+		{"random", 0, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			return interpreter.random.Float64(), nil
+		}},
+		{"randomInt", 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			lo, err := integerArgument(paren, "randomInt", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			hi, err := integerArgument(paren, "randomInt", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			if lo > hi {
+				return nil, RuntimeError{paren, fmt.Sprintf("randomInt range is empty, %d is greater than %d", lo, hi)}
+			}
+			return randomBetween(interpreter.random, int64(lo), int64(hi)), nil
+		}},
+		{"shuffle", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			list, err := listArgument(paren, "shuffle", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			interpreter.random.Shuffle(len(list.elements), func(i, j int) {
+				list.elements[i], list.elements[j] = list.elements[j], list.elements[i]
+			})
+			return nil, nil
+		}},
+		{"choice", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			list, err := listArgument(paren, "choice", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			if len(list.elements) == 0 {
+				return nil, RuntimeError{paren, "Cannot choose from an empty list"}
+			}
+			return list.elements[interpreter.random.Intn(len(list.elements))], nil
+		}},
+		{"seed", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			seed, err := integerArgument(paren, "seed", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			interpreter.random.Seed(int64(seed))
+			return nil, nil
+		}},
+	}
+}
+
+// randomBetween picks an integer from lo to hi inclusive, which can span
+// every int64
+func randomBetween(random *rand.Rand, lo int64, hi int64) int64 {
+	span := uint64(hi) - uint64(lo)
+	if span < math.MaxInt64 {
+		return lo + random.Int63n(int64(span)+1)
+	}
+
+	// Too wide for Int63n, so draw 64 bits and retry any beyond the range,
+	// which is at most half of them
+	for {
+		n := random.Uint64()
+		if n <= span {
+			return lo + int64(n)
+		}
+	}
+}
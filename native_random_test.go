@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestWithSeedIsReproducible(t *testing.T) {
+	source := `
+		print random();
+		print randomInt(1, 100);
+		var items = list(1, 2, 3, 4, 5, 6);
+		shuffle(items);
+		print items;
+		print choice(items);
+	`
+
+	first, err := runWithOptions(t, source, WithSeed(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := runWithOptions(t, source, WithSeed(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("expected the same output for the same seed, got %q and %q", first, second)
+	}
+
+	other, err := runWithOptions(t, source, WithSeed(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == other {
+		t.Errorf("expected different seeds to give different output, got %q for both", first)
+	}
+}
+
+func TestRandomIntExtremeBounds(t *testing.T) {
+	output, err := runWithOptions(t, `
+		var min = -9223372036854775807 - 1;
+		var max = 9223372036854775807;
+		var ok = true;
+		for (var i = 0; i < 200; i++) {
+			var n = randomInt(0, max);
+			if (n < 0) ok = false;
+			n = randomInt(min, max);
+			if (!isInteger(n)) ok = false;
+			n = randomInt(min, min + 1);
+			if (n != min and n != min + 1) ok = false;
+			n = randomInt(-1, max);
+			if (n < -1) ok = false;
+		}
+		print ok;
+		print randomInt(max, max) == max;
+		print randomInt(min, min) == min;
+	`, WithSeed(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "true\ntrue\ntrue\n" {
+		t.Errorf("expected every draw to be in range, got %q", output)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Regular expression natives take the pattern first, using Go's RE2 syntax
+
+func regexNatives() []*NativeFunction {
+	return []*NativeFunction{
+		{"regexMatch", 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			pattern, str, err := regexArguments(interpreter, paren, "regexMatch", arguments)
+			if err != nil {
+				return nil, err
+			}
+			return pattern.MatchString(str), nil
+		}},
+		{"regexFind", 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			pattern, str, err := regexArguments(interpreter, paren, "regexFind", arguments)
+			if err != nil {
+				return nil, err
+			}
+			match := pattern.FindStringIndex(str)
+			if match == nil {
+				return nil, nil
+			}
+			return str[match[0]:match[1]], nil
+		}},
+		{"regexFindAll", 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			pattern, str, err := regexArguments(interpreter, paren, "regexFindAll", arguments)
+			if err != nil {
+				return nil, err
+			}
+			return stringList(pattern.FindAllString(str, -1)), nil
+		}},
+		{"regexReplace", 3, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			pattern, str, err := regexArguments(interpreter, paren, "regexReplace", arguments)
+			if err != nil {
+				return nil, err
+			}
+			replacement, err := stringArgument(paren, "regexReplace", arguments, 2)
+			if err != nil {
+				return nil, err
+			}
+			return pattern.ReplaceAllString(str, replacement), nil
+		}},
+		{"regexSplit", 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			pattern, str, err := regexArguments(interpreter, paren, "regexSplit", arguments)
+			if err != nil {
+				return nil, err
+			}
+			return stringList(pattern.Split(str, -1)), nil
+		}},
+	}
+}
+
+func regexArguments(interpreter *Interpreter, paren Token, name string, arguments []interface{}) (*regexp.Regexp, string, LoxError) {
+	source, err := stringArgument(paren, name, arguments, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	str, err := stringArgument(paren, name, arguments, 1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pattern, err := interpreter.compilePattern(paren, source)
+	return pattern, str, err
+}
+
+// compilePattern compiles each distinct pattern once per interpreter
+func (i *Interpreter) compilePattern(paren Token, source string) (*regexp.Regexp, LoxError) {
+	if pattern, ok := i.patterns[source]; ok {
+		return pattern, nil
+	}
+
+	pattern, err := regexp.Compile(source)
+	if err != nil {
+		return nil, RuntimeError{paren, fmt.Sprintf("Invalid regular expression '%s': %s", source, err)}
+	}
+	i.patterns[source] = pattern
+	return pattern, nil
+}
+
+func stringList(strs []string) *LoxList {
+	elements := make([]interface{}, len(strs))
+	for i, str := range strs {
+		elements[i] = str
+	}
+	return NewLoxList(elements)
+}
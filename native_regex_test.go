@@ -0,0 +1,31 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+func TestRegexPatternsAreCached(t *testing.T) {
+	lox := NewLox(WithOutput(io.Discard))
+	err := lox.Run(`
+		regexMatch("a+", "aaa");
+		regexFind("a+", "baa");
+		regexMatch("b+", "b");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := lox.interpreter.patterns
+	if len(patterns) != 2 {
+		t.Fatalf("expected 2 cached patterns, got %d", len(patterns))
+	}
+
+	cached := patterns["a+"]
+	if err := lox.Run(`regexSplit("a+", "xaay");`); err != nil {
+		t.Fatal(err)
+	}
+	if patterns["a+"] != cached {
+		t.Error("expected the cached pattern to be reused")
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// String natives index by character rather than byte, so that non-ASCII
+// text can be sliced safely
+
+func stringNatives() []*NativeFunction {
+	return []*NativeFunction{
+		{"len", 1, nativeLen},
+		{"substring", 3, nativeSubstring},
+		{"indexOf", 2, nativeIndexOf},
+		stringPredicate("contains", strings.Contains),
+		stringPredicate("startsWith", strings.HasPrefix),
+		stringPredicate("endsWith", strings.HasSuffix),
+		{"split", 2, nativeSplit},
+		{"join", 2, nativeJoin},
+		{"replace", 3, nativeReplace},
+		stringTransform("upper", strings.ToUpper),
+		stringTransform("lower", strings.ToLower),
+		stringTransform("trim", strings.TrimSpace),
+		{"repeat", 2, nativeRepeat},
+		stringPadding("padStart", true),
+		stringPadding("padEnd", false),
+	}
+}
+
+func nativeLen(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	switch value := arguments[0].(type) {
+	case string:
+		return int64(utf8.RuneCountInString(value)), nil
+	case *LoxList:
+		return int64(len(value.elements)), nil
+	case *LoxMap:
+		return int64(len(value.keys)), nil
+	}
+	return nil, argumentError(paren, "len", 0, "string, list or map", arguments[0])
+}
+
+func nativeSubstring(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	str, err := stringArgument(paren, "substring", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, err := integerArgument(paren, "substring", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	end, err := integerArgument(paren, "substring", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(str)
+	if start < 0 || end > len(runes) || start > end {
+		return nil, RuntimeError{paren, fmt.Sprintf("Substring %d to %d is out of range for a string of length %d", start, end, len(runes))}
+	}
+	return string(runes[start:end]), nil
+}
+
+func nativeIndexOf(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	str, err := stringArgument(paren, "indexOf", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	search, err := stringArgument(paren, "indexOf", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	index := strings.Index(str, search)
+	if index < 0 {
+		return int64(-1), nil
+	}
+	return int64(utf8.RuneCountInString(str[:index])), nil
+}
+
+func nativeSplit(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	str, err := stringArgument(paren, "split", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	separator, err := stringArgument(paren, "split", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(str, separator)
+	elements := make([]interface{}, len(parts))
+	for i, part := range parts {
+		elements[i] = part
+	}
+	return NewLoxList(elements), nil
+}
+
+func nativeJoin(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	list, err := listArgument(paren, "join", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	separator, err := stringArgument(paren, "join", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]string, len(list.elements))
+	for i, element := range list.elements {
+		parts[i] = stringify(element)
+	}
+	return strings.Join(parts, separator), nil
+}
+
+func nativeReplace(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	strs := make([]string, 3)
+	for i := range strs {
+		str, err := stringArgument(paren, "replace", arguments, i)
+		if err != nil {
+			return nil, err
+		}
+		strs[i] = str
+	}
+	return strings.ReplaceAll(strs[0], strs[1], strs[2]), nil
+}
+
+func nativeRepeat(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+	str, err := stringArgument(paren, "repeat", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	count, err := integerArgument(paren, "repeat", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, RuntimeError{paren, "Cannot repeat a string a negative number of times"}
+	}
+	if str != "" && count > maxStringLength/len(str) {
+		return nil, tooLong(paren, "repeat")
+	}
+	return strings.Repeat(str, count), nil
+}
+
+// maxStringLength is the most bytes a native will build a string out of,
+// so a script asking for an absurd length gets an error rather than
+// exhausting memory
+const maxStringLength = 1 << 30
+
+func tooLong(paren Token, name string) LoxError {
+	return RuntimeError{paren, fmt.Sprintf("%s would make a string longer than %d bytes", name, maxStringLength)}
+}
+
+func stringPredicate(name string, fn func(string, string) bool) *NativeFunction {
+	return &NativeFunction{name, 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+		str, err := stringArgument(paren, name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		other, err := stringArgument(paren, name, arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+		return fn(str, other), nil
+	}}
+}
+
+func stringTransform(name string, fn func(string) string) *NativeFunction {
+	return &NativeFunction{name, 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+		str, err := stringArgument(paren, name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(str), nil
+	}}
+}
+
+// stringPadding pads a string to a width in characters, repeating the
+// padding string as many times as needed and cutting off any excess
+func stringPadding(name string, atStart bool) *NativeFunction {
+	return &NativeFunction{name, 3, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+		str, err := stringArgument(paren, name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		width, err := integerArgument(paren, name, arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+		padding, err := stringArgument(paren, name, arguments, 2)
+		if err != nil {
+			return nil, err
+		}
+		if padding == "" {
+			return nil, RuntimeError{paren, fmt.Sprintf("Cannot %s with an empty string", name)}
+		}
+
+		missing := width - utf8.RuneCountInString(str)
+		if missing <= 0 {
+			return str, nil
+		}
+		characters := utf8.RuneCountInString(padding)
+		repeats := (missing + characters - 1) / characters
+		if repeats > maxStringLength/len(padding) {
+			return nil, tooLong(paren, name)
+		}
+		pad := []rune(strings.Repeat(padding, repeats))[:missing]
+		if atStart {
+			return string(pad) + str, nil
+		}
+		return str + string(pad), nil
+	}}
+}
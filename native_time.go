@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Times are numbers of milliseconds since the Unix epoch, and durations are
+// numbers of milliseconds, so they can be added and subtracted directly. Both
+// are floats, as they can have fractions of a millisecond. Dates are
+// formatted, parsed and broken into components in UTC.
+
+// Clock is the source of time for the time natives, replaceable with
+// WithClock so scripts can be run deterministically
+type Clock interface {
+	Now() time.Time
+	Sleep(time.Duration)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// layouts are names that can be given in place of a Go layout string
+var layouts = map[string]string{
+	"RFC3339":  time.RFC3339,
+	"RFC1123":  time.RFC1123,
+	"Kitchen":  time.Kitchen,
+	"DateTime": "2006-01-02 15:04:05",
+	"DateOnly": "2006-01-02",
+	"TimeOnly": "15:04:05",
+}
+
+func timeNatives() []*NativeFunction {
+	return []*NativeFunction{
+		{"clock", 0, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			return milliseconds(interpreter.clock.Now()) / 1000, nil
+		}},
+		{"now", 0, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			return milliseconds(interpreter.clock.Now()), nil
+		}},
+		{"monotonic", 0, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			return durationMilliseconds(interpreter.clock.Now().Sub(interpreter.started)), nil
+		}},
+		{"sleep", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			d, err := durationArgument(paren, "sleep", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			if d > 0 {
+				interpreter.clock.Sleep(d)
+			}
+			return nil, nil
+		}},
+		{"formatTime", 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			t, err := timeArgument(paren, "formatTime", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			layout, err := layoutArgument(paren, "formatTime", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			return t.Format(layout), nil
+		}},
+		{"parseTime", 2, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			str, err := stringArgument(paren, "parseTime", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			layout, err := layoutArgument(paren, "parseTime", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			t, parseErr := time.Parse(layout, str)
+			if parseErr != nil {
+				return nil, RuntimeError{paren, fmt.Sprintf("Cannot parse '%s' as a time: %s", str, parseErr)}
+			}
+			return milliseconds(t), nil
+		}},
+		{"date", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			t, err := timeArgument(paren, "date", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			components := NewLoxMap()
+			components.Set("year", int64(t.Year()))
+			components.Set("month", int64(t.Month()))
+			components.Set("day", int64(t.Day()))
+			components.Set("hour", int64(t.Hour()))
+			components.Set("minute", int64(t.Minute()))
+			components.Set("second", int64(t.Second()))
+			components.Set("millisecond", int64(t.Nanosecond()/int(time.Millisecond)))
+			components.Set("weekday", int64(t.Weekday()))
+			components.Set("yearDay", int64(t.YearDay()))
+			return components, nil
+		}},
+		{"addDate", 4, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			t, err := timeArgument(paren, "addDate", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			years, err := integerArgument(paren, "addDate", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			months, err := integerArgument(paren, "addDate", arguments, 2)
+			if err != nil {
+				return nil, err
+			}
+			days, err := integerArgument(paren, "addDate", arguments, 3)
+			if err != nil {
+				return nil, err
+			}
+			return milliseconds(t.AddDate(years, months, days)), nil
+		}},
+		{"duration", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			str, err := stringArgument(paren, "duration", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			d, parseErr := time.ParseDuration(str)
+			if parseErr != nil {
+				return nil, RuntimeError{paren, fmt.Sprintf("Invalid duration '%s'", str)}
+			}
+			return durationMilliseconds(d), nil
+		}},
+		{"formatDuration", 1, func(interpreter *Interpreter, paren Token, arguments []interface{}) (interface{}, LoxError) {
+			d, err := durationArgument(paren, "formatDuration", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return d.String(), nil
+		}},
+	}
+}
+
+func milliseconds(t time.Time) float64 {
+	return float64(t.Unix())*1000 + float64(t.Nanosecond())/float64(time.Millisecond)
+}
+
+func durationMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// durationArgument converts a number of milliseconds to a duration, failing
+// if it's out of range
+func durationArgument(paren Token, name string, arguments []interface{}, index int) (time.Duration, LoxError) {
+	ms, err := numberArgument(paren, name, arguments, index)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(ms) || math.Abs(ms) > float64(math.MaxInt64/int64(time.Millisecond)) {
+		return 0, argumentError(paren, name, index, "duration", arguments[index])
+	}
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
+
+// timeArgument converts a timestamp in milliseconds to a UTC time
+func timeArgument(paren Token, name string, arguments []interface{}, index int) (time.Time, LoxError) {
+	ms, ok := toFloat(arguments[index])
+	if !ok || math.IsNaN(ms) || math.IsInf(ms, 0) {
+		return time.Time{}, argumentError(paren, name, index, "timestamp", arguments[index])
+	}
+
+	seconds := math.Floor(ms / 1000)
+	nanoseconds := math.Round((ms - seconds*1000) * float64(time.Millisecond))
+	return time.Unix(int64(seconds), int64(nanoseconds)).UTC(), nil
+}
+
+func layoutArgument(paren Token, name string, arguments []interface{}, index int) (string, LoxError) {
+	layout, err := stringArgument(paren, name, arguments, index)
+	if err != nil {
+		return "", err
+	}
+	if named, ok := layouts[layout]; ok {
+		return named, nil
+	}
+	return layout, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when slept on
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+}
+
+func TestTimeNativesUseTheClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 2, 29, 13, 45, 30, 250*int(time.Millisecond), time.UTC)}
+
+	output, err := runWithOptions(t, `
+		print now();
+		print clock();
+		print monotonic();
+		sleep(1500);
+		sleep(-5);
+		print monotonic();
+		print formatTime(now(), "RFC3339");
+	`, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "1709214330250\n1709214330.25\n0\n1500\n2024-02-29T13:45:31Z\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+	if len(clock.slept) != 1 || clock.slept[0] != 1500*time.Millisecond {
+		t.Errorf("expected a single sleep of 1.5s, got %v", clock.slept)
+	}
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Numbers come in four kinds: int64, from literals without a decimal point;
+// *big.Int, from literals with an n suffix; *Decimal, exact fixed-point
+// numbers from literals with an m suffix; and float64. Arithmetic on two
+// int64s stays exact, with division truncating toward zero, and overflow is
+// an error rather than wrapping around.
+//
+// When two kinds meet, the result is the later of int64, *big.Int, *Decimal
+// and float64, except that decimals and floats can't be mixed, as that would
+// bring back the rounding decimals are there to avoid.
+const (
+	intKind = iota
+	bigKind
+	decimalKind
+	floatKind
+)
+
+func numberKind(value interface{}) (int, bool) {
+	switch value.(type) {
+	case int64:
+		return intKind, true
+	case *big.Int:
+		return bigKind, true
+	case *Decimal:
+		return decimalKind, true
+	case float64:
+		return floatKind, true
+	}
+	return 0, false
+}
+
+func isNumber(value interface{}) bool {
+	_, ok := numberKind(value)
+	return ok
+}
+
+// toFloat widens any kind of number to a float
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int64:
+		return float64(n), true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f, true
+	case *Decimal:
+		return n.Float64(), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func toBig(value interface{}) *big.Int {
+	if n, ok := value.(int64); ok {
+		return big.NewInt(n)
+	}
+	return value.(*big.Int)
+}
+
+func toDecimal(value interface{}) *Decimal {
+	switch n := value.(type) {
+	case int64:
+		return decimalFromInt(big.NewInt(n))
+	case *big.Int:
+		return decimalFromInt(n)
+	}
+	return value.(*Decimal)
+}
+
+// toRat converts a finite number exactly
+func toRat(value interface{}) *big.Rat {
+	switch n := value.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(n)
+	case *big.Int:
+		return new(big.Rat).SetInt(n)
+	case *Decimal:
+		return n.Rat()
+	}
+	return new(big.Rat).SetFloat64(value.(float64))
+}
+
+// arithmetic applies +, -, *, / or % to two numbers
+func arithmetic(operator Token, left interface{}, right interface{}) (interface{}, LoxError) {
+	leftKind, _ := numberKind(left)
+	rightKind, _ := numberKind(right)
+	if leftKind == decimalKind && rightKind == floatKind || leftKind == floatKind && rightKind == decimalKind {
+		return nil, RuntimeError{operator, "Cannot mix decimals and floats, convert one with decimal or float"}
+	}
+
+	switch maxInt(leftKind, rightKind) {
+	case intKind:
+		return integerArithmetic(operator, left.(int64), right.(int64))
+	case bigKind:
+		return bigArithmetic(operator, toBig(left), toBig(right))
+	case decimalKind:
+		return decimalArithmetic(operator, toDecimal(left), toDecimal(right))
+	}
+
+	lf, _ := toFloat(left)
+	rf, _ := toFloat(right)
+	switch operator.tokenType {
+	case PLUS:
+		return lf + rf, nil
+	case MINUS:
+		return lf - rf, nil
+	case STAR:
+		return lf * rf, nil
+	case SLASH:
+		if rf == 0 {
+			return nil, divideByZero(operator)
+		}
+		return lf / rf, nil
+	case PERCENT:
+		if rf == 0 {
+			return nil, divideByZero(operator)
+		}
+		return math.Mod(lf, rf), nil
+	}
+	return nil, RuntimeError{operator, "Unknown arithmetic operator " + operator.lexeme}
+}
+
+func integerArithmetic(operator Token, l int64, r int64) (interface{}, LoxError) {
+	switch operator.tokenType {
+	case PLUS:
+		sum := l + r
+		if (l >= 0) == (r >= 0) && (sum >= 0) != (l >= 0) {
+			return nil, integerOverflow(operator)
+		}
+		return sum, nil
+	case MINUS:
+		difference := l - r
+		if (l >= 0) != (r >= 0) && (difference >= 0) != (l >= 0) {
+			return nil, integerOverflow(operator)
+		}
+		return difference, nil
+	case STAR:
+		if l == 0 || r == 0 {
+			return int64(0), nil
+		}
+		product := l * r
+		if product/r != l || (l == -1 && r == math.MinInt64) || (r == -1 && l == math.MinInt64) {
+			return nil, integerOverflow(operator)
+		}
+		return product, nil
+	case SLASH, PERCENT:
+		if r == 0 {
+			return nil, divideByZero(operator)
+		}
+		if operator.tokenType == PERCENT {
+			if r == -1 {
+				return int64(0), nil
+			}
+			return l % r, nil
+		}
+		if l == math.MinInt64 && r == -1 {
+			return nil, integerOverflow(operator)
+		}
+		return l / r, nil
+	}
+	return nil, RuntimeError{operator, "Unknown arithmetic operator " + operator.lexeme}
+}
+
+func bigArithmetic(operator Token, l *big.Int, r *big.Int) (interface{}, LoxError) {
+	switch operator.tokenType {
+	case PLUS:
+		return new(big.Int).Add(l, r), nil
+	case MINUS:
+		return new(big.Int).Sub(l, r), nil
+	case STAR:
+		return new(big.Int).Mul(l, r), nil
+	case SLASH:
+		if r.Sign() == 0 {
+			return nil, divideByZero(operator)
+		}
+		return new(big.Int).Quo(l, r), nil
+	case PERCENT:
+		if r.Sign() == 0 {
+			return nil, divideByZero(operator)
+		}
+		return new(big.Int).Rem(l, r), nil
+	}
+	return nil, RuntimeError{operator, "Unknown arithmetic operator " + operator.lexeme}
+}
+
+func decimalArithmetic(operator Token, l *Decimal, r *Decimal) (interface{}, LoxError) {
+	switch operator.tokenType {
+	case PLUS:
+		return l.Add(r), nil
+	case MINUS:
+		return l.Sub(r), nil
+	case STAR:
+		return l.Mul(r), nil
+	case SLASH:
+		if r.Sign() == 0 {
+			return nil, divideByZero(operator)
+		}
+		return l.Quo(r), nil
+	case PERCENT:
+		if r.Sign() == 0 {
+			return nil, divideByZero(operator)
+		}
+		return l.Rem(r), nil
+	}
+	return nil, RuntimeError{operator, "Unknown arithmetic operator " + operator.lexeme}
+}
+
+// bitwise applies &, |, ^, << or >> to two integers, which may be int64 or
+// big. Shifting an int64 left is an error if it loses bits, and >> keeps the
+// sign
+func bitwise(operator Token, left interface{}, right interface{}) (interface{}, LoxError) {
+	leftKind, leftOk := numberKind(left)
+	rightKind, rightOk := numberKind(right)
+	if !leftOk || !rightOk || leftKind > bigKind || rightKind > bigKind {
+		return nil, RuntimeError{operator, "Operands must be integers"}
+	}
+
+	if operator.tokenType == LESS_LESS || operator.tokenType == GREATER_GREATER {
+		return shift(operator, left, toBig(right))
+	}
+
+	if leftKind == intKind && rightKind == intKind {
+		l, r := left.(int64), right.(int64)
+		switch operator.tokenType {
+		case AMPERSAND:
+			return l & r, nil
+		case PIPE:
+			return l | r, nil
+		case CARET:
+			return l ^ r, nil
+		}
+	}
+
+	l, r := toBig(left), toBig(right)
+	switch operator.tokenType {
+	case AMPERSAND:
+		return new(big.Int).And(l, r), nil
+	case PIPE:
+		return new(big.Int).Or(l, r), nil
+	case CARET:
+		return new(big.Int).Xor(l, r), nil
+	}
+	return nil, RuntimeError{operator, "Unknown bitwise operator " + operator.lexeme}
+}
+
+func shift(operator Token, value interface{}, count *big.Int) (interface{}, LoxError) {
+	if count.Sign() < 0 {
+		return nil, RuntimeError{operator, "Shift count must not be negative"}
+	}
+
+	n, isInt := value.(int64)
+	if isInt && operator.tokenType == GREATER_GREATER {
+		if !count.IsInt64() || count.Int64() > 63 {
+			count = big.NewInt(63)
+		}
+		return n >> uint(count.Int64()), nil
+	}
+	if isInt {
+		if n == 0 {
+			return int64(0), nil
+		}
+		if !count.IsInt64() || count.Int64() > 63 || n<<uint(count.Int64())>>uint(count.Int64()) != n {
+			return nil, integerOverflow(operator)
+		}
+		return n << uint(count.Int64()), nil
+	}
+
+	integer := value.(*big.Int)
+	if operator.tokenType == GREATER_GREATER {
+		// Shifting out every bit leaves only the sign
+		if !count.IsInt64() {
+			if integer.Sign() < 0 {
+				return big.NewInt(-1), nil
+			}
+			return big.NewInt(0), nil
+		}
+		return new(big.Int).Rsh(integer, uint(count.Int64())), nil
+	}
+	if integer.Sign() == 0 {
+		return integer, nil
+	}
+	if !count.IsInt64() || count.Int64() > maxBigShift {
+		return nil, RuntimeError{operator, "Shift count is too large"}
+	}
+	return new(big.Int).Lsh(integer, uint(count.Int64())), nil
+}
+
+// maxBigShift limits how large shifting a big integer left can make it
+const maxBigShift = 1 << 20
+
+func bitwiseNot(operator Token, value interface{}) (interface{}, LoxError) {
+	switch n := value.(type) {
+	case int64:
+		return ^n, nil
+	case *big.Int:
+		return new(big.Int).Not(n), nil
+	}
+	return nil, RuntimeError{operator, "Operand must be an integer"}
+}
+
+// compareNumbers applies <, <=, > or >= to two numbers. Whatever their kinds,
+// they're compared exactly, and nothing compares with NaN
+func compareNumbers(operator Token, left interface{}, right interface{}) bool {
+	order, ok := compareValues(left, right)
+	if !ok {
+		return false
+	}
+
+	switch operator.tokenType {
+	case GREATER:
+		return order > 0
+	case GREATER_EQUAL:
+		return order >= 0
+	case LESS:
+		return order < 0
+	case LESS_EQUAL:
+		return order <= 0
+	}
+	return false
+}
+
+// compareValues orders two numbers, or reports that they can't be as one is
+// NaN
+func compareValues(left interface{}, right interface{}) (int, bool) {
+	l, leftInt := left.(int64)
+	r, rightInt := right.(int64)
+	if leftInt && rightInt {
+		switch {
+		case l < r:
+			return -1, true
+		case l > r:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	// Infinities have no exact equivalent, but compare as floats just as well
+	lf, leftFloat := left.(float64)
+	rf, rightFloat := right.(float64)
+	if leftFloat && (math.IsNaN(lf) || math.IsInf(lf, 0)) || rightFloat && (math.IsNaN(rf) || math.IsInf(rf, 0)) {
+		lf, _ = toFloat(left)
+		rf, _ = toFloat(right)
+		switch {
+		case math.IsNaN(lf) || math.IsNaN(rf):
+			return 0, false
+		case lf < rf:
+			return -1, true
+		case lf > rf:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	return toRat(left).Cmp(toRat(right)), true
+}
+
+func negate(operator Token, value interface{}) (interface{}, LoxError) {
+	switch n := value.(type) {
+	case int64:
+		if n == math.MinInt64 {
+			return nil, integerOverflow(operator)
+		}
+		return -n, nil
+	case *big.Int:
+		return new(big.Int).Neg(n), nil
+	case *Decimal:
+		return n.Neg(), nil
+	case float64:
+		return -n, nil
+	}
+	return nil, RuntimeError{operator, "Operand must be a number"}
+}
+
+// numbersEqual compares numbers of any kind by value, so 1 == 1.0
+func numbersEqual(a interface{}, b interface{}) bool {
+	order, ok := compareValues(a, b)
+	return ok && order == 0
+}
+
+// truncate drops any fractional part of a number, failing for NaN and the
+// infinities
+func truncate(value interface{}) (*big.Int, bool) {
+	switch n := value.(type) {
+	case int64:
+		return big.NewInt(n), true
+	case *big.Int:
+		return n, true
+	case *Decimal:
+		return new(big.Int).Quo(n.unscaled, pow10(n.scale)), true
+	case float64:
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, false
+		}
+		integer, _ := big.NewFloat(math.Trunc(n)).Int(nil)
+		return integer, true
+	}
+	return nil, false
+}
+
+// wholeFloat converts a float to an integer if it has no fractional part and
+// is in range
+func wholeFloat(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func formatInteger(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func divideByZero(operator Token) LoxError {
+	return DivideZeroError{RuntimeError{operator, "Cannot divide by zero"}}
+}
+
+func integerOverflow(operator Token) LoxError {
+	return RuntimeError{operator, "Integer overflow"}
+}
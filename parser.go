@@ -1,8 +1,8 @@
 package main
 
 import (
-	"errors"
 	"fmt"
+	"strings"
 )
 
 func NewParser() *Parser {
@@ -12,11 +12,40 @@ func NewParser() *Parser {
 type Parser struct {
 	tokens  []Token
 	current int
+
+	// docs holds the text of the doc comments before a token, by its index
+	docs map[int]string
+
+	// lastID is the ID given to the last variable reference parsed. It
+	// carries on across loads so every reference gets its own
+	lastID ExprID
 }
 
+// Load sets the tokens to parse, taking out doc comments so they can only be
+// attached to the declarations they come before
 func (p *Parser) Load(tokens []Token) {
-	p.tokens = tokens
+	p.tokens = make([]Token, 0, len(tokens))
+	p.docs = make(map[int]string)
 	p.current = 0
+
+	var doc []string
+	for _, token := range tokens {
+		if token.tokenType == DOC_COMMENT {
+			doc = append(doc, token.literal.(string))
+			continue
+		}
+		if doc != nil {
+			p.docs[len(p.tokens)] = strings.Join(doc, "\n")
+			doc = nil
+		}
+		p.tokens = append(p.tokens, token)
+	}
+}
+
+// nextID gives out the ID of a new variable reference
+func (p *Parser) nextID() ExprID {
+	p.lastID++
+	return p.lastID
 }
 
 func (p *Parser) Parse() ([]Stmt, error) {
@@ -33,15 +62,29 @@ func (p *Parser) Parse() ([]Stmt, error) {
 	return statements, nil
 }
 
+// ParseExpression parses a single expression spanning all of the tokens
+func (p *Parser) ParseExpression() (Expr, error) {
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isAtEnd() {
+		return nil, p.error(p.peek(), "Expected end of expression")
+	}
+	return expr, nil
+}
+
 // Grammar
 
 func (p *Parser) declaration() (Stmt, error) {
 	var result Stmt
 	var err error
+	doc := p.docs[p.current]
 	if p.match(VAR) {
-		result, err = p.varDeclaration()
+		result, err = p.varDeclaration(doc)
 	} else if p.match(FUN) {
-		result, err = p.function("function")
+		result, err = p.function("function", doc)
 	} else {
 		result, err = p.statement()
 	}
@@ -53,7 +96,7 @@ func (p *Parser) declaration() (Stmt, error) {
 	return result, nil
 }
 
-func (p *Parser) varDeclaration() (Stmt, error) {
+func (p *Parser) varDeclaration(doc string) (Stmt, error) {
 	name, err := p.consume(IDENTIFIER, "Expected variable name")
 	if err != nil {
 		return nil, err
@@ -72,10 +115,10 @@ func (p *Parser) varDeclaration() (Stmt, error) {
 	if err != nil {
 		return nil, err
 	}
-	return Var{name, init}, nil
+	return Var{name, init, doc}, nil
 }
 
-func (p *Parser) function(kind string) (Stmt, error) {
+func (p *Parser) function(kind string, doc string) (Stmt, error) {
 	name, err := p.consume(IDENTIFIER, fmt.Sprintf("Expected %s name", kind))
 	if err != nil {
 		return nil, err
@@ -115,7 +158,7 @@ func (p *Parser) function(kind string) (Stmt, error) {
 	}
 
 	body, err := p.block()
-	return Function{name, parameters, body}, err
+	return Function{name, parameters, body, doc}, err
 }
 
 func (p *Parser) statement() (Stmt, error) {
@@ -227,7 +270,7 @@ func (p *Parser) forStatement() (Stmt, error) {
 	if p.match(SEMICOLON) {
 		initializer = nil
 	} else if p.match(VAR) {
-		initializer, err = p.varDeclaration()
+		initializer, err = p.varDeclaration("")
 		if err != nil {
 			return nil, err
 		}
@@ -317,7 +360,7 @@ func (p *Parser) expression() (Expr, error) {
 }
 
 func (p *Parser) assignment() (Expr, error) {
-	expr, err := p.or()
+	expr, err := p.conditional()
 	if err != nil {
 		return nil, err
 	}
@@ -330,10 +373,65 @@ func (p *Parser) assignment() (Expr, error) {
 		}
 		if assign, ok := expr.(Variable); ok {
 			name := assign.Name
-			return Assign{name, value}, nil
+			return Assign{name, value, assign.ID}, nil
 		}
 		return nil, p.error(equals, "Invalid assignment target")
 	}
+
+	if p.match(PLUS_EQUAL, MINUS_EQUAL, STAR_EQUAL, SLASH_EQUAL, PERCENT_EQUAL) {
+		operator := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		if variable, ok := expr.(Variable); ok {
+			return CompoundAssign{variable.Name, operator, value, variable.ID}, nil
+		}
+		return nil, p.error(operator, "Invalid assignment target")
+	}
+	return expr, nil
+}
+
+func (p *Parser) conditional() (Expr, error) {
+	expr, err := p.coalesce()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(QUESTION) {
+		then, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(COLON, "':' expected after then branch of conditional expression")
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		return Conditional{expr, then, elseBranch}, nil
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) coalesce() (Expr, error) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(QUESTION_QUESTION) {
+		operator := p.previous()
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		expr = Logical{expr, operator, right}
+	}
+
 	return expr, nil
 }
 
@@ -346,7 +444,10 @@ func (p *Parser) or() (Expr, error) {
 	for p.match(OR) {
 		operator := p.previous()
 		right, err := p.and()
-		return Logical{expr, operator, right}, err
+		if err != nil {
+			return nil, err
+		}
+		expr = Logical{expr, operator, right}
 	}
 
 	return expr, nil
@@ -361,19 +462,94 @@ func (p *Parser) and() (Expr, error) {
 	for p.match(AND) {
 		operator := p.previous()
 		right, err := p.equality()
-		return Logical{expr, operator, right}, err
+		if err != nil {
+			return nil, err
+		}
+		expr = Logical{expr, operator, right}
 	}
 
 	return expr, nil
 }
 
 func (p *Parser) equality() (Expr, error) {
-	expr, err := p.comparison()
+	expr, err := p.bitwiseOr()
 	if err != nil {
 		return nil, err
 	}
 
 	for p.match(BANG_EQUAL, EQUAL_EQUAL) {
+		operator := p.previous()
+		right, err := p.bitwiseOr()
+		if err != nil {
+			return nil, err
+		}
+		expr = Binary{expr, operator, right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) bitwiseOr() (Expr, error) {
+	expr, err := p.bitwiseXor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(PIPE) {
+		operator := p.previous()
+		right, err := p.bitwiseXor()
+		if err != nil {
+			return nil, err
+		}
+		expr = Binary{expr, operator, right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) bitwiseXor() (Expr, error) {
+	expr, err := p.bitwiseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(CARET) {
+		operator := p.previous()
+		right, err := p.bitwiseAnd()
+		if err != nil {
+			return nil, err
+		}
+		expr = Binary{expr, operator, right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) bitwiseAnd() (Expr, error) {
+	expr, err := p.shift()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(AMPERSAND) {
+		operator := p.previous()
+		right, err := p.shift()
+		if err != nil {
+			return nil, err
+		}
+		expr = Binary{expr, operator, right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) shift() (Expr, error) {
+	expr, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(LESS_LESS, GREATER_GREATER) {
 		operator := p.previous()
 		right, err := p.comparison()
 		if err != nil {
@@ -440,7 +616,7 @@ func (p *Parser) factor() (Expr, error) {
 }
 
 func (p *Parser) unary() (Expr, error) {
-	if p.match(BANG, MINUS) {
+	if p.match(BANG, MINUS, TILDE) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -449,7 +625,36 @@ func (p *Parser) unary() (Expr, error) {
 		return Unary{operator, right}, nil
 	}
 
-	return p.call()
+	if p.match(PLUS_PLUS, MINUS_MINUS) {
+		operator := p.previous()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if variable, ok := operand.(Variable); ok {
+			return Increment{variable.Name, operator, true, variable.ID}, nil
+		}
+		return nil, p.error(operator, "Invalid increment target")
+	}
+
+	return p.postfix()
+}
+
+func (p *Parser) postfix() (Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(PLUS_PLUS, MINUS_MINUS) {
+		operator := p.previous()
+		if variable, ok := expr.(Variable); ok {
+			return Increment{variable.Name, operator, false, variable.ID}, nil
+		}
+		return nil, p.error(operator, "Invalid increment target")
+	}
+
+	return expr, nil
 }
 
 func (p *Parser) call() (Expr, error) {
@@ -517,6 +722,10 @@ func (p *Parser) primary() (Expr, error) {
 		return Literal{p.previous().literal}, nil
 	}
 
+	if p.match(INTERPOLATION) {
+		return p.interpolation()
+	}
+
 	if p.match(LEFT_PAREN) {
 		expr, err := p.expression()
 		if err != nil {
@@ -527,12 +736,37 @@ func (p *Parser) primary() (Expr, error) {
 	}
 
 	if p.match(IDENTIFIER) {
-		return Variable{p.previous()}, nil
+		return Variable{p.previous(), p.nextID()}, nil
 	}
 
 	return nil, p.error(p.previous(), "Unexpected token")
 }
 
+// interpolation parses the holes and text of a string after its first
+// INTERPOLATION token, up to the STRING token that ends it
+func (p *Parser) interpolation() (Expr, error) {
+	var parts []Expr
+	for {
+		fragment := p.previous()
+		if text := fragment.literal.(string); text != "" {
+			parts = append(parts, Literal{text})
+		}
+		if fragment.tokenType == STRING {
+			return Interpolation{parts}, nil
+		}
+
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, expr)
+
+		if !p.match(INTERPOLATION, STRING) {
+			return nil, p.error(p.peek(), "Expect '}' after string interpolation")
+		}
+	}
+}
+
 func (p *Parser) synchronize() {
 	p.advance()
 
@@ -567,11 +801,11 @@ func (p *Parser) consume(until Lexeme, message string) (Token, error) {
 }
 
 func (p *Parser) error(token Token, message string) error {
-	var err error
+	var err CompileError
 	if token.tokenType == EOF {
-		err = errors.New(fmt.Sprintf("%s at end", message))
+		err = CompileError{token, fmt.Sprintf("%s at end", message)}
 	} else {
-		err = errors.New(fmt.Sprintf("%s at %s", message, token.lexeme))
+		err = CompileError{token, fmt.Sprintf("%s at %s", message, token.lexeme)}
 	}
 
 	report(err, token.line)
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestParserDocComments(t *testing.T) {
+	ast, err := NewLox().Parse(`
+		/// Adds two numbers.
+		///
+		/// Works with any kind of number.
+		fun add(a, b) {
+			/// The sum.
+			var sum = a + b;
+			return sum;
+		}
+
+		/// Not attached to a statement.
+		print add(1, 2);
+		var undocumented;
+		// An ordinary comment.
+		var plain;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	add := ast[0].(Function)
+	if add.Doc != "Adds two numbers.\n\nWorks with any kind of number." {
+		t.Errorf("unexpected doc for add: %q", add.Doc)
+	}
+	if sum := add.Body.Statements[0].(Var); sum.Doc != "The sum." {
+		t.Errorf("unexpected doc for sum: %q", sum.Doc)
+	}
+	for _, stmt := range ast[2:] {
+		if v := stmt.(Var); v.Doc != "" {
+			t.Errorf("expected %s to have no doc, got %q", v.Name.lexeme, v.Doc)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+const replHelp = `Enter Lox statements to run them. Input continues over several lines until
+braces and parentheses are balanced and the statement ends with ';' or '}'.
+Enter a blank line to run incomplete input anyway. The values of expression
+statements are printed.
+
+Commands:
+  :help         show this message
+  :reset        discard all definitions and start a fresh session
+  :load <file>  run a script in the current session
+  :env          list the global variables defined in this session
+  :ast <expr>   show the syntax tree of an expression
+`
+
+// NewRepl starts a session, creating its interpreter with the given options.
+// Scripts print to out unless the options say otherwise
+func NewRepl(lines LineReader, out io.Writer, options ...Option) *Repl {
+	options = append([]Option{WithOutput(out)}, options...)
+	return &Repl{NewLox(options...), lines, out, options}
+}
+
+type Repl struct {
+	lox     *Lox
+	lines   LineReader
+	out     io.Writer
+	options []Option
+}
+
+// Run reads and runs input until it runs out or a script calls exit,
+// returning the status to exit with
+func (r *Repl) Run() int {
+	for {
+		source, ok := r.readInput()
+		if !ok {
+			fmt.Fprintln(r.out)
+			return 0
+		}
+
+		var err error
+		if strings.HasPrefix(strings.TrimSpace(source), ":") {
+			err = r.command(strings.TrimSpace(source))
+		} else {
+			err = r.eval(source)
+		}
+		if exit, ok := err.(ExitError); ok {
+			return exit.Code
+		}
+	}
+}
+
+// readInput reads lines until they form a complete piece of input
+func (r *Repl) readInput() (string, bool) {
+	prompt := "> "
+	source := ""
+	for {
+		line, err := r.lines.ReadLine(prompt)
+		if err == errInterrupted {
+			source, prompt = "", "> "
+			continue
+		}
+		if err != nil {
+			return source, source != ""
+		}
+		line += "\n"
+
+		if source == "" && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			return line, true
+		}
+		if source != "" && strings.TrimSpace(line) == "" {
+			return source, true
+		}
+
+		source += line
+		if !isIncomplete(source) {
+			return source, true
+		}
+		prompt = "... "
+	}
+}
+
+// eval runs source, returning any error after it has been reported
+func (r *Repl) eval(source string) error {
+	stmts, err := r.lox.Parse(source)
+	if err != nil {
+		return err
+	}
+
+	NewResolver(r.lox.interpreter).Resolve(stmts)
+	for _, stmt := range stmts {
+		if expr, ok := stmt.(Expression); ok {
+			value, err := r.lox.interpreter.Evaluate(expr.Expression)
+			if err != nil {
+				return err
+			}
+			if value != nil {
+				fmt.Fprintln(r.out, stringify(value))
+			}
+			continue
+		}
+
+		if err := r.lox.interpreter.Interpret([]Stmt{stmt}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repl) command(input string) error {
+	name, argument := input, ""
+	if i := strings.IndexAny(input, " \t"); i >= 0 {
+		name, argument = input[:i], strings.TrimSpace(input[i+1:])
+	}
+
+	switch name {
+	case ":help":
+		fmt.Fprint(r.out, replHelp)
+	case ":reset":
+		r.lox = NewLox(r.options...)
+	case ":load":
+		if argument == "" {
+			fmt.Fprintln(r.out, "Usage: :load <file>")
+			return nil
+		}
+		content, err := ioutil.ReadFile(argument)
+		if err != nil {
+			report(err, 0)
+			return nil
+		}
+		return r.lox.Run(string(content))
+	case ":env":
+		globals := r.lox.interpreter.globals.values
+		names := make([]string, 0, len(globals))
+		for name := range globals {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(r.out, "%s = %s\n", name, stringify(globals[name]))
+		}
+	case ":ast":
+		expr, err := r.lox.ParseExpression(argument)
+		if err != nil {
+			return nil
+		}
+		fmt.Fprintln(r.out, NewAstPrinter().PrintExpr(expr))
+	default:
+		fmt.Fprintf(r.out, "Unknown command %s, try :help\n", name)
+	}
+	return nil
+}
+
+// complete offers keywords and global names for tab completion
+func (r *Repl) complete(prefix string) []string {
+	candidates := make([]string, 0)
+	for keyword := range keywords {
+		if strings.HasPrefix(keyword, prefix) {
+			candidates = append(candidates, keyword)
+		}
+	}
+	seen := make(map[string]bool)
+	for env := r.lox.interpreter.globals; env != nil; env = env.enclosed {
+		for name := range env.values {
+			if !seen[name] && strings.HasPrefix(name, prefix) {
+				candidates = append(candidates, name)
+				seen[name] = true
+			}
+		}
+	}
+
+	sort.Strings(candidates)
+	return candidates
+}
+
+// isIncomplete reports whether source needs more lines before it can be
+// parsed: when a string or comment is left open, brackets are unbalanced, or
+// the last statement hasn't been terminated
+func isIncomplete(source string) bool {
+	scanner := NewScanner(source)
+	scanner.scanTokens()
+
+	for _, err := range scanner.errors {
+		switch err.Error() {
+		case "unterminated string", "unterminated string interpolation", "unterminated block comment":
+			return true
+		}
+	}
+
+	depth := 0
+	for _, token := range scanner.tokens {
+		switch token.tokenType {
+		case LEFT_PAREN, LEFT_BRACE:
+			depth++
+		case RIGHT_PAREN, RIGHT_BRACE:
+			depth--
+		}
+	}
+	if depth > 0 {
+		return true
+	}
+
+	tokens := scanner.tokens[:len(scanner.tokens)-1]
+	if len(tokens) == 0 || depth < 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1].tokenType
+	return last != SEMICOLON && last != RIGHT_BRACE
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestIsIncomplete(t *testing.T) {
+	cases := map[string]bool{
+		"":                              false,
+		"print 1;":                      false,
+		"print 1":                       true,
+		"fun f() {":                     true,
+		"fun f() {\n  return 1;\n}":     false,
+		"f(1,\n":                        true,
+		"print \"open":                  true,
+		"print \"two\nlines\";":         false,
+		"if (a) { print a; } else":      true,
+		"}":                             false,
+		"var a = 1; // trailing remark": false,
+	}
+
+	for source, expected := range cases {
+		if actual := isIncomplete(source); actual != expected {
+			t.Errorf("isIncomplete(%q): expected %t, got %t", source, expected, actual)
+		}
+	}
+}
+
+func TestReplSession(t *testing.T) {
+	input := strings.Join([]string{
+		"fun add(a,",
+		"        b) {",
+		"  return a + b;",
+		"}",
+		"add(1, 2);",
+		"var x = 5;",
+		"x;",
+		"print x * 2;",
+		":ast 1 + -x",
+		":env",
+		":reset",
+		":env",
+		"1 +",
+		"",
+		":nope",
+	}, "\n")
+
+	var out strings.Builder
+	captureStderr(t, func() {
+		NewRepl(NewLineReader(strings.NewReader(input), &out), &out).Run()
+	})
+
+	expected := []string{
+		"> ... ... ... > 3",
+		"> > 5",
+		"> 10",
+		"> (+ 1 (- x))",
+		"> add = <fn add>",
+		"x = 5",
+		"> > > ... > Unknown command :nope, try :help",
+		"> ",
+		"",
+	}
+	if actual := out.String(); actual != strings.Join(expected, "\n") {
+		t.Errorf("unexpected session output:\n%s", actual)
+	}
+}
+
+// Every input is scanned from line 1, so references to the same name in the
+// same place in different inputs must still be resolved separately
+func TestReplResolvesEachInput(t *testing.T) {
+	sessions := map[string][]string{
+		"global in the same place": {
+			"fun f(a) { return a; }",
+			"var a = 5;print   a;",
+			"print f(3);",
+		},
+		"deeper local in the same place": {
+			"fun f(a) { return a; }",
+			"{var a=1;{{{{{    a; }}}}}}",
+			"print f(3);",
+		},
+	}
+
+	for name, lines := range sessions {
+		t.Run(name, func(t *testing.T) {
+			var out strings.Builder
+			stderr := captureStderr(t, func() {
+				NewRepl(NewLineReader(strings.NewReader(strings.Join(lines, "\n")), io.Discard), &out).Run()
+			})
+
+			printed := strings.Fields(out.String())
+			if len(printed) == 0 || printed[len(printed)-1] != "3" || stderr != "" {
+				t.Errorf("expected f(3) to print 3, got %q\n%s", out.String(), stderr)
+			}
+		})
+	}
+}
@@ -76,17 +76,28 @@ func (r *Resolver) VisitVariableExpr(v Variable) (interface{}, LoxError) {
 		}
 	}
 
-	r.resolveLocal(v, v.Name)
+	r.resolveLocal(v.ID, v.Name)
 	return nil, nil
 }
 
 func (r *Resolver) VisitAssignExpr(expr Assign) (interface{}, LoxError) {
 	r.resolveExpr(expr.Value)
-	r.resolveLocal(expr, expr.Name)
+	r.resolveLocal(expr.ID, expr.Name)
 
 	return nil, nil
 }
 
+// Compound assignments and increments read their variable before writing it,
+// so they resolve as a read would
+func (r *Resolver) VisitCompoundAssignExpr(expr CompoundAssign) (interface{}, LoxError) {
+	r.resolveExpr(expr.Value)
+	return r.VisitVariableExpr(Variable{expr.Name, expr.ID})
+}
+
+func (r *Resolver) VisitIncrementExpr(expr Increment) (interface{}, LoxError) {
+	return r.VisitVariableExpr(Variable{expr.Name, expr.ID})
+}
+
 func (r *Resolver) VisitFunctionStmt(fun Function) LoxError {
 	r.declare(fun.Name)
 	r.define(fun.Name)
@@ -96,7 +107,7 @@ func (r *Resolver) VisitFunctionStmt(fun Function) LoxError {
 }
 
 func (r *Resolver) VisitExpressionStmt(expr Expression) LoxError {
-	r.resolveStmt(expr)
+	r.resolveExpr(expr.Expression)
 	return nil
 }
 
@@ -151,6 +162,22 @@ func (r *Resolver) VisitLiteralExpr(lit Literal) (interface{}, LoxError) {
 	return nil, nil
 }
 
+func (r *Resolver) VisitInterpolationExpr(expr Interpolation) (interface{}, LoxError) {
+	for _, part := range expr.Parts {
+		r.resolveExpr(part)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitConditionalExpr(expr Conditional) (interface{}, LoxError) {
+	r.resolveExpr(expr.Condition)
+	r.resolveExpr(expr.Then)
+	r.resolveExpr(expr.Else)
+
+	return nil, nil
+}
+
 func (r *Resolver) VisitLogicalExpr(log Logical) (interface{}, LoxError) {
 	r.resolveExpr(log.Left)
 	r.resolveExpr(log.Right)
@@ -165,10 +192,11 @@ func (r *Resolver) VisitUnaryExpr(unary Unary) (interface{}, LoxError) {
 
 // Helpers
 
-func (r *Resolver) resolveLocal(expr Expr, name Token) {
-	for i, scope := range r.scopes {
-		if _, ok := scope[name.lexeme]; ok {
-			r.interpreter.resolve(expr, int64(len(r.scopes)-1-i))
+func (r *Resolver) resolveLocal(id ExprID, name Token) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name.lexeme]; ok {
+			r.interpreter.resolve(id, int64(len(r.scopes)-1-i))
+			return
 		}
 	}
 }
@@ -179,6 +207,6 @@ func (r *Resolver) resolveFunction(fun Function) {
 		r.declare(param)
 		r.define(param)
 	}
-	r.resolveStmt(fun.Body)
+	r.resolveStmt(fun.Body.Statements...)
 	r.endScope()
 }
@@ -1,10 +1,12 @@
 package main
 
 import (
-	"errors"
 	"fmt"
-	"regexp"
+	"math/big"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 var keywords = map[string]Lexeme{
@@ -28,44 +30,88 @@ var keywords = map[string]Lexeme{
 
 func NewScanner(source string) *Scanner {
 	return &Scanner{
-		source:        source,
+		source:        []rune(source),
 		start:         0,
 		line:          1,
+		startLine:     1,
+		lineStart:     0,
+		column:        1,
 		current:       0,
 		containsError: false,
+		errors:        make([]LoxError, 0),
 		tokens:        make([]Token, 0)}
 }
 
+// Scanner works on the characters of a script rather than its bytes, so
+// columns count characters and offsets index into source
 type Scanner struct {
-	source        string
+	source        []rune
 	start         int
 	line          int
+	startLine     int
+	lineStart     int
+	column        int
 	current       int
 	containsError bool
+	errors        []LoxError
 	tokens        []Token
+
+	// interpolations holds the strings whose ${ holes are being scanned,
+	// innermost last
+	interpolations []openString
+}
+
+// openString is a string literal being scanned
+type openString struct {
+	triple    bool  // delimited by """, with its indentation stripped
+	raw       bool  // without escapes or interpolation
+	fragments []int // indexes in tokens of the string's fragments so far
+
+	// braces counts the unclosed braces in the ${ hole being scanned, so the
+	// } that ends the hole can be told apart from one that ends a block
+	braces int
 }
 
 func (s *Scanner) Scan() {
+	s.scanTokens()
+	for _, err := range s.errors {
+		report(err, err.Token().line)
+	}
+}
+
+// scanTokens scans without reporting, leaving errors in s.errors
+func (s *Scanner) scanTokens() {
 	for !s.isAtEnd() {
-		s.start = s.current
+		s.markStart()
 		err := s.scan()
 		if err != nil {
-			report(err, s.line)
-			s.containsError = true
+			s.error(err.Error())
 		}
 	}
 
+	s.markStart()
+	if len(s.interpolations) > 0 {
+		s.error("unterminated string interpolation")
+	}
 	s.tokenize(EOF, nil)
 }
 
+// Tokens are positioned at their first character, even when they span lines
+func (s *Scanner) markStart() {
+	s.start = s.current
+	s.startLine = s.line
+	s.column = s.start - s.lineStart + 1
+}
+
 func (s *Scanner) tokenize(lex Lexeme, literal interface{}) error {
-	text := s.source[s.start:s.current]
+	text := s.text(s.start, s.current)
 
 	s.tokens = append(s.tokens, Token{
 		tokenType: lex,
 		lexeme:    text,
 		literal:   literal,
-		line:      s.line,
+		line:      s.startLine,
+		column:    s.column,
 	})
 
 	return nil
@@ -82,9 +128,23 @@ func (s *Scanner) scan() error {
 		s.tokenize(RIGHT_PAREN, nil)
 		break
 	case "{":
+		if len(s.interpolations) > 0 {
+			s.interpolations[len(s.interpolations)-1].braces++
+		}
 		s.tokenize(LEFT_BRACE, nil)
 		break
 	case "}":
+		if n := len(s.interpolations); n > 0 {
+			if str := s.interpolations[n-1]; str.braces == 0 {
+				s.interpolations = s.interpolations[:n-1]
+				if s.tokens[len(s.tokens)-1].tokenType == INTERPOLATION {
+					s.error("empty string interpolation")
+				}
+				s.scanString(s.current, str)
+				break
+			}
+			s.interpolations[n-1].braces--
+		}
 		s.tokenize(RIGHT_BRACE, nil)
 		break
 	case ",":
@@ -93,20 +153,53 @@ func (s *Scanner) scan() error {
 	case ".":
 		s.tokenize(DOT, nil)
 		break
+	case "?":
+		if s.matchNext("?") {
+			s.tokenize(QUESTION_QUESTION, nil)
+		} else {
+			s.tokenize(QUESTION, nil)
+		}
+	case ":":
+		s.tokenize(COLON, nil)
 	case "-":
-		s.tokenize(MINUS, nil)
-		break
+		if s.matchNext("=") {
+			s.tokenize(MINUS_EQUAL, nil)
+		} else if s.matchNext("-") {
+			s.tokenize(MINUS_MINUS, nil)
+		} else {
+			s.tokenize(MINUS, nil)
+		}
 	case "+":
-		s.tokenize(PLUS, nil)
-		break
+		if s.matchNext("=") {
+			s.tokenize(PLUS_EQUAL, nil)
+		} else if s.matchNext("+") {
+			s.tokenize(PLUS_PLUS, nil)
+		} else {
+			s.tokenize(PLUS, nil)
+		}
 	case ";":
 		s.tokenize(SEMICOLON, nil)
 		break
 	case "*":
-		s.tokenize(STAR, nil)
-		break
+		if s.matchNext("=") {
+			s.tokenize(STAR_EQUAL, nil)
+		} else {
+			s.tokenize(STAR, nil)
+		}
 	case "%":
-		s.tokenize(PERCENT, nil)
+		if s.matchNext("=") {
+			s.tokenize(PERCENT_EQUAL, nil)
+		} else {
+			s.tokenize(PERCENT, nil)
+		}
+	case "&":
+		s.tokenize(AMPERSAND, nil)
+	case "|":
+		s.tokenize(PIPE, nil)
+	case "^":
+		s.tokenize(CARET, nil)
+	case "~":
+		s.tokenize(TILDE, nil)
 	case "!":
 		if s.matchNext("=") {
 			s.tokenize(BANG_EQUAL, nil)
@@ -123,6 +216,8 @@ func (s *Scanner) scan() error {
 	case "<":
 		if s.matchNext("=") {
 			s.tokenize(LESS_EQUAL, nil)
+		} else if s.matchNext("<") {
+			s.tokenize(LESS_LESS, nil)
 		} else {
 			s.tokenize(LESS, nil)
 		}
@@ -130,15 +225,26 @@ func (s *Scanner) scan() error {
 	case ">":
 		if s.matchNext("=") {
 			s.tokenize(GREATER_EQUAL, nil)
+		} else if s.matchNext(">") {
+			s.tokenize(GREATER_GREATER, nil)
 		} else {
 			s.tokenize(GREATER, nil)
 		}
 		break
 	case "/":
 		if s.matchNext("/") {
+			doc := s.peek(0) == "/" && s.peek(1) != "/"
 			for s.peek(0) != "\n" && !s.isAtEnd() {
 				s.advance()
 			}
+			if doc {
+				text := strings.TrimSuffix(s.text(s.start+3, s.current), "\r")
+				s.tokenize(DOC_COMMENT, strings.TrimPrefix(text, " "))
+			}
+		} else if s.matchNext("*") {
+			s.skipBlockComment()
+		} else if s.matchNext("=") {
+			s.tokenize(SLASH_EQUAL, nil)
 		} else {
 			s.tokenize(SLASH, nil)
 		}
@@ -150,14 +256,21 @@ func (s *Scanner) scan() error {
 		break
 
 	case "\n":
-		s.line++
+		s.newline()
 		break
 
 	case "\"":
-		s.scanString()
+		triple := s.matchTriple()
+		s.scanString(s.current, openString{triple: triple})
 		break
 
 	default:
+		if char == "r" && s.matchNext("\"") {
+			triple := s.matchTriple()
+			s.scanString(s.current, openString{triple: triple, raw: true})
+			break
+		}
+
 		if isDigit(char) {
 			s.scanNumber()
 			break
@@ -174,6 +287,30 @@ func (s *Scanner) scan() error {
 	return nil
 }
 
+func (s *Scanner) error(message string) {
+	err := CompileError{Token{
+		lexeme: s.text(s.start, s.current),
+		line:   s.startLine,
+		column: s.column,
+	}, message}
+
+	s.errors = append(s.errors, err)
+	s.containsError = true
+}
+
+// errorAt reports an error from offset up to the current character, all on
+// the current line
+func (s *Scanner) errorAt(offset int, message string) {
+	err := CompileError{Token{
+		lexeme: s.text(offset, s.current),
+		line:   s.line,
+		column: offset - s.lineStart + 1,
+	}, message}
+
+	s.errors = append(s.errors, err)
+	s.containsError = true
+}
+
 func (s *Scanner) isAtEnd() bool {
 	return s.current >= len(s.source)
 }
@@ -183,6 +320,15 @@ func (s *Scanner) advance() string {
 	return string(s.source[s.current-1])
 }
 
+func (s *Scanner) previous() string {
+	return string(s.source[s.current-1])
+}
+
+func (s *Scanner) newline() {
+	s.line++
+	s.lineStart = s.current
+}
+
 func (s *Scanner) matchNext(expected string) bool {
 	if s.peek(0) != expected {
 		return false
@@ -192,50 +338,460 @@ func (s *Scanner) matchNext(expected string) bool {
 	return true
 }
 
+// lookahead reports whether the source continues with text
+func (s *Scanner) lookahead(text string) bool {
+	i := s.current
+	for _, char := range text {
+		if i >= len(s.source) || s.source[i] != char {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// text is the source from one offset to another
+func (s *Scanner) text(from int, to int) string {
+	return string(s.source[from:to])
+}
+
 func (s *Scanner) peek(next int) string {
-	if s.isAtEnd() {
-		return "0"
+	if s.current+next >= len(s.source) {
+		return ""
 	}
 	return string(s.source[s.current+next])
 }
 
+// scanNumber scans a number literal, which may be:
+//
+//	an integer, 1_000_000, or in another base, 0xFF, 0b1010 or 0o17
+//	a float, with a fraction or exponent, 1.5 or 1.5e-3
+//	a big integer with an n suffix, 10n or 0xFFn
+//	a decimal with an m suffix, 19.99m or 1.5e3m
+//
+// Underscores can separate digits, but not start or end a run of them
 func (s *Scanner) scanNumber() {
-	for isDigit(s.peek(0)) {
+	if s.previous() == "0" && s.peek(0) != "" && strings.Contains("xXbBoO", s.peek(0)) {
+		s.scanBasedNumber()
+		return
+	}
+
+	if !s.scanDigits(10, "number") {
+		return
+	}
+
+	fractional := s.peek(0) == "." && isDigit(s.peek(1))
+	if fractional {
 		s.advance()
+		if !s.scanDigits(10, "number") {
+			return
+		}
 	}
 
-	if s.peek(0) == "." && isDigit(s.peek(1)) {
+	exponent := s.peek(0) == "e" || s.peek(0) == "E"
+	if exponent {
 		s.advance()
+		if s.peek(0) == "+" || s.peek(0) == "-" {
+			s.advance()
+		}
+		if !isDigit(s.peek(0)) {
+			s.numberError("exponent has no digits")
+			return
+		}
+		if !s.scanDigits(10, "number") {
+			return
+		}
 	}
 
-	for isDigit(s.peek(0)) {
+	text := strings.ReplaceAll(s.text(s.start, s.current), "_", "")
+	suffix := s.peek(0)
+	if suffix == "n" || suffix == "m" {
 		s.advance()
 	}
+	if s.invalidSuffix() {
+		return
+	}
 
-	literal, err := strconv.ParseFloat(s.source[s.start:s.current], 64)
-	if err != nil {
-		report(errors.New("could not parse number"), s.line)
-		s.containsError = true
+	switch {
+	case suffix == "m":
+		literal, err := parseDecimal(text)
+		if err == errDecimalRange {
+			s.error("decimal literal out of range")
+			return
+		}
+		if err != nil {
+			s.error("could not parse number")
+			return
+		}
+		s.tokenize(NUMBER, literal)
+	case suffix == "n":
+		if fractional || exponent {
+			s.error("big integer literal can't have a fraction or exponent")
+			return
+		}
+		literal, _ := new(big.Int).SetString(text, 10)
+		s.tokenize(NUMBER, literal)
+	case fractional || exponent:
+		literal, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			s.error("float literal out of range")
+			return
+		}
+		s.tokenize(NUMBER, literal)
+	default:
+		// Numbers without a fraction or exponent are integers
+		literal, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			s.error("integer literal out of range")
+			return
+		}
+		s.tokenize(NUMBER, literal)
+	}
+}
+
+// scanBasedNumber scans an integer after its leading 0, in hex, binary or
+// octal
+func (s *Scanner) scanBasedNumber() {
+	base, name := 16, "hex"
+	switch strings.ToLower(s.advance()) {
+	case "b":
+		base, name = 2, "binary"
+	case "o":
+		base, name = 8, "octal"
+	}
+
+	if !s.scanDigits(base, name) {
+		return
+	}
+	digits := strings.ReplaceAll(s.text(s.start+2, s.current), "_", "")
+	if digits == "" {
+		s.numberError(name + " literal has no digits")
+		return
+	}
+
+	suffix := s.peek(0)
+	if suffix == "n" {
+		s.advance()
+	} else if suffix == "m" {
+		s.numberError("decimal literal must be written in base 10")
+		return
+	}
+	if s.invalidSuffix() {
 		return
 	}
 
+	if suffix == "n" {
+		literal, _ := new(big.Int).SetString(digits, base)
+		s.tokenize(NUMBER, literal)
+		return
+	}
+	literal, err := strconv.ParseInt(digits, base, 64)
+	if err != nil {
+		s.error("integer literal out of range")
+		return
+	}
 	s.tokenize(NUMBER, literal)
 }
 
-func (s *Scanner) scanString() {
-	for s.peek(0) != "\"" && !s.isAtEnd() {
-		if s.peek(0) == "\n" {
-			s.line++
+// scanDigits consumes a run of digits and underscores, reporting an error if
+// a digit is out of range for the base or an underscore isn't between two
+// digits
+func (s *Scanner) scanDigits(base int, name string) bool {
+	start := s.current
+	for s.peek(0) == "_" || isDigit(s.peek(0)) || base == 16 && isHexLetter(s.peek(0)) {
+		s.advance()
+	}
+	run := s.text(start, s.current)
+
+	// A run continues from a digit before it, as with the first digit of a
+	// number, which has already been scanned
+	leading := strings.HasPrefix(run, "_") && !isDigit(s.text(start-1, start))
+	if leading || strings.HasSuffix(run, "_") || strings.Contains(run, "__") {
+		s.numberError("underscores in numbers must be between digits")
+		return false
+	}
+
+	for _, digit := range run {
+		if value, err := strconv.ParseInt(string(digit), 16, 64); err == nil && int(value) >= base {
+			s.numberError(fmt.Sprintf("invalid digit '%c' in %s literal", digit, name))
+			return false
 		}
+	}
+	return true
+}
+
+// invalidSuffix reports letters straight after a number, which can't be told
+// apart from a mistyped number
+func (s *Scanner) invalidSuffix() bool {
+	if !isAlpha(s.peek(0)) && s.peek(0) != "_" {
+		return false
+	}
+
+	start := s.current
+	s.skipNumber()
+	s.error(fmt.Sprintf("invalid suffix '%s' on number", s.text(start, s.current)))
+	return true
+}
+
+// numberError reports a malformed number, skipping the rest of it so it
+// isn't scanned as further tokens
+func (s *Scanner) numberError(message string) {
+	s.skipNumber()
+	s.error(message)
+}
+
+// skipNumber skips what's left of a malformed number
+func (s *Scanner) skipNumber() {
+	for isAlphaNumeric(s.peek(0)) || s.peek(0) == "_" || s.peek(0) == "." && isDigit(s.peek(1)) {
 		s.advance()
 	}
+}
+
+// skipBlockComment skips to the end of a /* comment, which can contain
+// other block comments
+func (s *Scanner) skipBlockComment() {
+	depth := 1
+	for depth > 0 {
+		if s.isAtEnd() {
+			s.error("unterminated block comment")
+			return
+		}
+
+		if s.lookahead("/*") {
+			s.current += 2
+			depth++
+		} else if s.lookahead("*/") {
+			s.current += 2
+			depth--
+		} else if s.advance() == "\n" {
+			s.newline()
+		}
+	}
+}
+
+// matchTriple consumes the rest of a """ after its first quote. Two quotes
+// alone are an empty string, not the start of a triple-quoted one
+func (s *Scanner) matchTriple() bool {
+	if s.peek(0) != "\"" || s.peek(1) != "\"" {
+		return false
+	}
+	s.advance()
+	s.advance()
+	return true
+}
+
+// scanString scans a string from contentStart up to its closing quotes or the
+// start of an interpolated ${ hole. A string with holes becomes an
+// INTERPOLATION token for the text before each hole, followed by the tokens
+// inside it, and ends with a STRING token for the rest once the hole's } is
+// reached. A triple-quoted string has its indentation stripped once the whole
+// of it has been scanned, as holes can come between its lines
+func (s *Scanner) scanString(contentStart int, str openString) {
+	quote := "\""
+	if str.triple {
+		quote = "\"\"\""
+	}
+
+	for !s.lookahead(quote) {
+		if s.isAtEnd() {
+			s.error("unterminated string")
+			return
+		}
+		if !str.raw && s.lookahead("${") {
+			str.fragments = append(str.fragments, len(s.tokens))
+			text := s.text(contentStart, s.current)
+			s.advance()
+			s.advance()
+			s.interpolations = append(s.interpolations, str)
+			s.tokenize(INTERPOLATION, stringText(text, str))
+			return
+		}
+
+		char := s.advance()
+		if char == "\\" && !str.raw {
+			s.scanEscape()
+		} else if char == "\n" {
+			s.newline()
+		}
+	}
+
+	text := s.text(contentStart, s.current)
+	s.current += len(quote)
+	str.fragments = append(str.fragments, len(s.tokens))
+	s.tokenize(STRING, stringText(text, str))
+
+	if str.triple {
+		texts := make([]string, len(str.fragments))
+		for i, index := range str.fragments {
+			texts[i] = s.tokens[index].literal.(string)
+		}
+		for i, text := range dedent(texts) {
+			if !str.raw {
+				text = unescape(text)
+			}
+			s.tokens[str.fragments[i]].literal = text
+		}
+	}
+}
+
+// stringText is the value of a fragment of a string, leaving the escapes in
+// a triple-quoted string until its indentation has been stripped
+func stringText(text string, str openString) string {
+	if str.raw || str.triple {
+		return text
+	}
+	return unescape(text)
+}
+
+// scanEscape checks the escape sequence after a backslash, which can be one
+// of \n, \t, \r, \0, \\, \", \$ or a unicode code point in hex, \u{1F600}
+func (s *Scanner) scanEscape() {
+	start := s.current - 1
 	if s.isAtEnd() {
-		report(errors.New("unterminated string"), s.line)
-		s.containsError = true
 		return
 	}
-	s.advance()
-	s.tokenize(STRING, s.source[s.start+1:s.current-1])
+
+	switch s.advance() {
+	case "n", "t", "r", "0", "\\", "\"", "$":
+		return
+	case "u":
+		if !s.matchNext("{") {
+			break
+		}
+		for isDigit(s.peek(0)) || isHexLetter(s.peek(0)) {
+			s.advance()
+		}
+		if !s.matchNext("}") {
+			break
+		}
+		if _, ok := parseCodePoint(s.text(start+3, s.current-1)); !ok {
+			s.errorAt(start, fmt.Sprintf("invalid unicode code point '%s'", s.text(start, s.current)))
+		}
+		return
+	case "\n":
+		s.errorAt(start, "invalid escape sequence '\\' at end of line")
+		s.newline()
+		return
+	}
+	s.errorAt(start, fmt.Sprintf("invalid escape sequence '%s'", s.text(start, s.current)))
+}
+
+func parseCodePoint(hex string) (rune, bool) {
+	if len(hex) == 0 || len(hex) > 6 {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(value)) {
+		return 0, false
+	}
+	return rune(value), true
+}
+
+var escapes = map[byte]string{
+	'n':  "\n",
+	't':  "\t",
+	'r':  "\r",
+	'0':  "\x00",
+	'\\': "\\",
+	'"':  "\"",
+	'$':  "$",
+}
+
+// unescape replaces the escape sequences in text, leaving any invalid ones,
+// which the scanner has already reported, as they are
+func unescape(text string) string {
+	if !strings.Contains(text, "\\") {
+		return text
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 == len(text) {
+			result.WriteByte(text[i])
+			continue
+		}
+
+		if replacement, ok := escapes[text[i+1]]; ok {
+			result.WriteString(replacement)
+			i++
+			continue
+		}
+		if text[i+1] == 'u' && i+2 < len(text) && text[i+2] == '{' {
+			if end := strings.IndexByte(text[i:], '}'); end >= 0 {
+				if r, ok := parseCodePoint(text[i+3 : i+end]); ok {
+					result.WriteRune(r)
+					i += end
+					continue
+				}
+			}
+		}
+		result.WriteByte(text[i])
+	}
+	return result.String()
+}
+
+// dedent strips the common indentation from the lines of a triple-quoted
+// string, given as the text between its holes. Only a string that starts on
+// a new line is stripped, and that first newline isn't part of it. If the
+// closing quotes are on a line of their own, that line isn't part of it
+// either, and its indentation counts towards the common indentation. Blank
+// lines don't count
+func dedent(texts []string) []string {
+	texts = append([]string(nil), texts...)
+	if strings.HasPrefix(texts[0], "\r\n") {
+		texts[0] = texts[0][2:]
+	} else if strings.HasPrefix(texts[0], "\n") {
+		texts[0] = texts[0][1:]
+	} else {
+		return texts
+	}
+
+	last := len(texts) - 1
+	common := -1
+	if i := strings.LastIndex(texts[last], "\n"); i >= 0 && indentation(texts[last][i+1:]) == len(texts[last][i+1:]) {
+		common = len(texts[last]) - i - 1
+		texts[last] = strings.TrimSuffix(texts[last][:i], "\r")
+	}
+
+	// A line starts each text after the first only once it has had a newline,
+	// as the text follows a hole on the same line
+	lines := make([][]string, len(texts))
+	for i, text := range texts {
+		lines[i] = strings.Split(text, "\n")
+		for j, line := range lines[i] {
+			if j == 0 && i > 0 {
+				continue
+			}
+			indent := indentation(line)
+			endsAtHole := j == len(lines[i])-1 && i < last
+			if (indent < len(strings.TrimSuffix(line, "\r")) || endsAtHole) && (common < 0 || indent < common) {
+				common = indent
+			}
+		}
+	}
+	if common <= 0 {
+		return texts
+	}
+
+	for i := range lines {
+		for j, line := range lines[i] {
+			if j == 0 && i > 0 {
+				continue
+			}
+			strip := indentation(line)
+			if strip > common {
+				strip = common
+			}
+			lines[i][j] = line[strip:]
+		}
+		texts[i] = strings.Join(lines[i], "\n")
+	}
+	return texts
+}
+
+// indentation is the number of spaces and tabs at the start of line
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
 }
 
 func (s *Scanner) scanIdentifier() {
@@ -243,7 +799,7 @@ func (s *Scanner) scanIdentifier() {
 		s.advance()
 	}
 
-	text := s.source[s.start:s.current]
+	text := s.text(s.start, s.current)
 	keyword, ok := keywords[text]
 	if ok {
 		s.tokenize(keyword, nil)
@@ -257,11 +813,20 @@ func isDigit(char string) bool {
 	return err == nil
 }
 
+func isHexLetter(char string) bool {
+	return char != "" && strings.Contains("abcdefABCDEF", char)
+}
+
+// isAlpha reports whether char can start an identifier, which it can if it's
+// a letter in any script or an underscore
 func isAlpha(char string) bool {
-	reggie := regexp.MustCompile("[a-zA-Z]")
-	return reggie.Match([]byte(char))
+	r, _ := utf8.DecodeRuneInString(char)
+	return char != "" && (unicode.IsLetter(r) || r == '_')
 }
 
+// isAlphaNumeric reports whether char can continue an identifier, which also
+// allows digits and the combining marks some scripts need to spell words
 func isAlphaNumeric(char string) bool {
-	return isAlpha(char) || isDigit(char)
+	r, _ := utf8.DecodeRuneInString(char)
+	return isAlpha(char) || unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc)
 }
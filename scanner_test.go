@@ -0,0 +1,354 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestScannerPositions(t *testing.T) {
+	scanner := NewScanner("var a = 1;\n  print \"two\nlines\";")
+	scanner.Scan()
+
+	expected := []struct {
+		tokenType Lexeme
+		lexeme    string
+		line      int
+		column    int
+	}{
+		{VAR, "var", 1, 1},
+		{IDENTIFIER, "a", 1, 5},
+		{EQUAL, "=", 1, 7},
+		{NUMBER, "1", 1, 9},
+		{SEMICOLON, ";", 1, 10},
+		{PRINT, "print", 2, 3},
+		{STRING, "\"two\nlines\"", 2, 9},
+		{SEMICOLON, ";", 3, 7},
+		{EOF, "", 3, 8},
+	}
+
+	if len(scanner.tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d: %v", len(expected), len(scanner.tokens), scanner.tokens)
+	}
+	for i, e := range expected {
+		token := scanner.tokens[i]
+		if token.tokenType != e.tokenType || token.lexeme != e.lexeme || token.line != e.line || token.column != e.column {
+			t.Errorf("token %d: expected %s %q at %d:%d, got %s %q at %d:%d",
+				i, e.tokenType, e.lexeme, e.line, e.column, token.tokenType, token.lexeme, token.line, token.column)
+		}
+	}
+}
+
+func TestScannerErrors(t *testing.T) {
+	var scanner *Scanner
+	captureStderr(t, func() {
+		scanner = NewScanner("var a = @;\n\"open")
+		scanner.Scan()
+	})
+
+	if !scanner.containsError {
+		t.Fatal("expected scanner to report an error")
+	}
+	if len(scanner.errors) != 2 {
+		t.Fatalf("expected 2 errors, got %v", scanner.errors)
+	}
+
+	first := scanner.errors[0]
+	if first.Error() != "unexpected character: @" || first.Token().line != 1 || first.Token().column != 9 {
+		t.Errorf("unexpected first error %q at %d:%d", first, first.Token().line, first.Token().column)
+	}
+	second := scanner.errors[1]
+	if second.Error() != "unterminated string" || second.Token().line != 2 || second.Token().column != 1 {
+		t.Errorf("unexpected second error %q at %d:%d", second, second.Token().line, second.Token().column)
+	}
+}
+
+func TestScannerNumberLiterals(t *testing.T) {
+	scanner := NewScanner("42 4.2 9223372036854775807")
+	scanner.scanTokens()
+
+	expected := []interface{}{int64(42), 4.2, int64(9223372036854775807)}
+	for i, literal := range expected {
+		if actual := scanner.tokens[i].literal; actual != literal {
+			t.Errorf("token %d: expected %#v, got %#v", i, literal, actual)
+		}
+	}
+
+	scanner = NewScanner("9223372036854775808")
+	scanner.scanTokens()
+	if len(scanner.errors) != 1 || scanner.errors[0].Error() != "integer literal out of range" {
+		t.Errorf("expected an out of range error, got %v", scanner.errors)
+	}
+}
+
+func TestScannerNumberSuffixes(t *testing.T) {
+	scanner := NewScanner("9223372036854775808n 1.50m 7m")
+	scanner.scanTokens()
+
+	expected := []string{"9223372036854775808n", "1.50m", "7m"}
+	for i, lexeme := range expected {
+		if actual := scanner.tokens[i].lexeme; actual != lexeme {
+			t.Errorf("token %d: expected %q, got %q", i, lexeme, actual)
+		}
+	}
+	if integer, ok := scanner.tokens[0].literal.(*big.Int); !ok || integer.String() != "9223372036854775808" {
+		t.Errorf("expected a big integer, got %#v", scanner.tokens[0].literal)
+	}
+	if decimal, ok := scanner.tokens[1].literal.(*Decimal); !ok || decimal.String() != "1.50" {
+		t.Errorf("expected a decimal, got %#v", scanner.tokens[1].literal)
+	}
+
+	scanner = NewScanner("1.5n")
+	scanner.scanTokens()
+	if len(scanner.errors) != 1 || scanner.errors[0].Error() != "big integer literal can't have a fraction or exponent" {
+		t.Errorf("expected a fraction error, got %v", scanner.errors)
+	}
+}
+
+func TestScannerNumberFormats(t *testing.T) {
+	cases := map[string]interface{}{
+		"0xFF":        int64(255),
+		"0Xff":        int64(255),
+		"0b1010":      int64(10),
+		"0o17":        int64(15),
+		"0x_FF":       nil,
+		"1_000_000":   int64(1000000),
+		"0b1111_0000": int64(240),
+		"1.5e-3":      0.0015,
+		"2E3":         2000.0,
+		"1_0.2_5e+1":  102.5,
+		"007":         int64(7),
+	}
+
+	for source, expected := range cases {
+		scanner := NewScanner(source)
+		scanner.scanTokens()
+		if expected == nil {
+			if len(scanner.errors) == 0 {
+				t.Errorf("%s: expected an error", source)
+			}
+			continue
+		}
+		if len(scanner.errors) > 0 || len(scanner.tokens) != 2 || scanner.tokens[0].literal != expected {
+			t.Errorf("%s: expected %#v, got %v with errors %v", source, expected, scanner.tokens, scanner.errors)
+		}
+	}
+
+	scanner := NewScanner("0xFFFFFFFFFFFFFFFFFn 0b1n 1e3m")
+	scanner.scanTokens()
+	expected := []string{"295147905179352825855", "1", "1000"}
+	for i, text := range expected {
+		if actual := stringify(scanner.tokens[i].literal); actual != text {
+			t.Errorf("token %d: expected %s, got %s", i, text, actual)
+		}
+	}
+}
+
+func TestScannerMalformedNumbers(t *testing.T) {
+	cases := map[string]string{
+		"0x":                      "hex literal has no digits",
+		"0b":                      "binary literal has no digits",
+		"0b102":                   "invalid digit '2' in binary literal",
+		"0o78":                    "invalid digit '8' in octal literal",
+		"1_":                      "underscores in numbers must be between digits",
+		"1__000":                  "underscores in numbers must be between digits",
+		"1_.5":                    "underscores in numbers must be between digits",
+		"1.5_":                    "underscores in numbers must be between digits",
+		"1e":                      "exponent has no digits",
+		"1e+":                     "exponent has no digits",
+		"1e_3":                    "exponent has no digits",
+		"12abc":                   "invalid suffix 'abc' on number",
+		"0xFFg":                   "invalid suffix 'g' on number",
+		"0xFFm":                   "decimal literal must be written in base 10",
+		"1e3n":                    "big integer literal can't have a fraction or exponent",
+		"1e400":                   "float literal out of range",
+		"0x1_0000_0000_0000_0000": "integer literal out of range",
+		"1e999999999m":            "decimal literal out of range",
+		"1e-10001m":               "decimal literal out of range",
+		"1e99999999999999999999m": "decimal literal out of range",
+	}
+
+	for source, message := range cases {
+		scanner := NewScanner(source + ";")
+		scanner.scanTokens()
+		if len(scanner.errors) != 1 || scanner.errors[0].Error() != message {
+			t.Errorf("%s: expected error %q, got %v", source, message, scanner.errors)
+			continue
+		}
+		if len(scanner.tokens) != 2 || scanner.tokens[0].tokenType != SEMICOLON {
+			t.Errorf("%s: expected the rest of the number to be skipped, got %v", source, scanner.tokens)
+		}
+	}
+}
+
+func TestScannerInterpolation(t *testing.T) {
+	scanner := NewScanner("\"a ${b + {}.c} d\n${\n\"${e}\"} f\"")
+	scanner.Scan()
+
+	expected := []struct {
+		tokenType Lexeme
+		literal   interface{}
+		line      int
+	}{
+		{INTERPOLATION, "a ", 1},
+		{IDENTIFIER, nil, 1},
+		{PLUS, nil, 1},
+		{LEFT_BRACE, nil, 1},
+		{RIGHT_BRACE, nil, 1},
+		{DOT, nil, 1},
+		{IDENTIFIER, nil, 1},
+		{INTERPOLATION, " d\n", 1},
+		{INTERPOLATION, "", 3},
+		{IDENTIFIER, nil, 3},
+		{STRING, "", 3},
+		{STRING, " f", 3},
+		{EOF, nil, 3},
+	}
+
+	if len(scanner.tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d: %v", len(expected), len(scanner.tokens), scanner.tokens)
+	}
+	for i, e := range expected {
+		token := scanner.tokens[i]
+		if token.tokenType != e.tokenType || token.literal != e.literal || token.line != e.line {
+			t.Errorf("token %d: expected %s %q on line %d, got %s %q on line %d",
+				i, e.tokenType, e.literal, e.line, token.tokenType, token.literal, token.line)
+		}
+	}
+}
+
+func TestScannerInterpolationErrors(t *testing.T) {
+	cases := map[string]string{
+		`"a ${b`:    "unterminated string interpolation",
+		`"a ${b} c`: "unterminated string",
+		`"a ${} c"`: "empty string interpolation",
+		`"a ${{}`:   "unterminated string interpolation",
+	}
+
+	for source, expected := range cases {
+		scanner := NewScanner(source)
+		scanner.scanTokens()
+
+		if len(scanner.errors) != 1 || scanner.errors[0].Error() != expected {
+			t.Errorf("%s: expected error %q, got %v", source, expected, scanner.errors)
+		}
+	}
+}
+
+func TestScannerEscapeErrors(t *testing.T) {
+	scanner := NewScanner("var a = \"ok\\n\";\nvar b = \"x\\q \\u{110000} \\u{zz}\";\nvar c = r\"\\q\";")
+	scanner.scanTokens()
+
+	expected := []struct {
+		message string
+		line    int
+		column  int
+	}{
+		{`invalid escape sequence '\q'`, 2, 11},
+		{`invalid unicode code point '\u{110000}'`, 2, 14},
+		{`invalid escape sequence '\u{'`, 2, 25},
+	}
+
+	if len(scanner.errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), scanner.errors)
+	}
+	for i, e := range expected {
+		err := scanner.errors[i]
+		if err.Error() != e.message || err.Token().line != e.line || err.Token().column != e.column {
+			t.Errorf("error %d: expected %q at %d:%d, got %q at %d:%d",
+				i, e.message, e.line, e.column, err, err.Token().line, err.Token().column)
+		}
+	}
+}
+
+func TestScannerTripleQuotedStrings(t *testing.T) {
+	scanner := NewScanner("\"\"\"\n  a \"quoted\"\n   b\n  \"\"\" x\n\"\"\"open")
+	scanner.scanTokens()
+
+	str, identifier := scanner.tokens[0], scanner.tokens[1]
+	if str.tokenType != STRING || str.literal != "a \"quoted\"\n b" {
+		t.Errorf("expected the string's indentation to be stripped, got %s %q", str.tokenType, str.literal)
+	}
+	if identifier.lexeme != "x" || identifier.line != 4 || identifier.column != 7 {
+		t.Errorf("expected x at 4:7, got %q at %d:%d", identifier.lexeme, identifier.line, identifier.column)
+	}
+	if len(scanner.errors) != 1 || scanner.errors[0].Error() != "unterminated string" || scanner.errors[0].Token().line != 5 {
+		t.Errorf("expected an unterminated string on line 5, got %v", scanner.errors)
+	}
+}
+
+func TestScannerComments(t *testing.T) {
+	scanner := NewScanner("/* a /* b */\n*/ x ///  doc\r\n//// not doc\n/// more\ny /* open\n/*")
+	scanner.scanTokens()
+
+	expected := []struct {
+		tokenType Lexeme
+		literal   interface{}
+		line      int
+	}{
+		{IDENTIFIER, nil, 2},
+		{DOC_COMMENT, " doc", 2},
+		{DOC_COMMENT, "more", 4},
+		{IDENTIFIER, nil, 5},
+		{EOF, nil, 6},
+	}
+
+	if len(scanner.tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d: %v", len(expected), len(scanner.tokens), scanner.tokens)
+	}
+	for i, e := range expected {
+		token := scanner.tokens[i]
+		if token.tokenType != e.tokenType || token.literal != e.literal || token.line != e.line {
+			t.Errorf("token %d: expected %s %q on line %d, got %s %q on line %d",
+				i, e.tokenType, e.literal, e.line, token.tokenType, token.literal, token.line)
+		}
+	}
+
+	if len(scanner.errors) != 1 || scanner.errors[0].Error() != "unterminated block comment" || scanner.errors[0].Token().line != 5 {
+		t.Errorf("expected an unterminated block comment on line 5, got %v", scanner.errors)
+	}
+}
+
+func TestScannerUnicode(t *testing.T) {
+	scanner := NewScanner("var café = \"日本語 😀\";\nprint नमस्ते + _x9 € ü;")
+	scanner.scanTokens()
+
+	expected := []struct {
+		tokenType Lexeme
+		lexeme    string
+		column    int
+	}{
+		{VAR, "var", 1},
+		{IDENTIFIER, "café", 5},
+		{EQUAL, "=", 10},
+		{STRING, "\"日本語 😀\"", 12},
+		{SEMICOLON, ";", 19},
+		{PRINT, "print", 1},
+		{IDENTIFIER, "नमस्ते", 7},
+		{PLUS, "+", 14},
+		{IDENTIFIER, "_x9", 16},
+		{IDENTIFIER, "ü", 22},
+		{SEMICOLON, ";", 23},
+		{EOF, "", 24},
+	}
+
+	if len(scanner.tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d: %v", len(expected), len(scanner.tokens), scanner.tokens)
+	}
+	for i, e := range expected {
+		token := scanner.tokens[i]
+		if token.tokenType != e.tokenType || token.lexeme != e.lexeme || token.column != e.column {
+			t.Errorf("token %d: expected %s %q at column %d, got %s %q at column %d",
+				i, e.tokenType, e.lexeme, e.column, token.tokenType, token.lexeme, token.column)
+		}
+	}
+	if literal := scanner.tokens[3].literal; literal != "日本語 😀" {
+		t.Errorf("expected the string to keep its characters, got %q", literal)
+	}
+
+	if len(scanner.errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", scanner.errors)
+	}
+	if err := scanner.errors[0]; err.Error() != "unexpected character: €" || err.Token().line != 2 || err.Token().column != 20 {
+		t.Errorf("unexpected error %q at %d:%d", err, err.Token().line, err.Token().column)
+	}
+}
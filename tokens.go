@@ -5,46 +5,64 @@ import "fmt"
 type Lexeme string
 
 const (
-	LEFT_PAREN    Lexeme = "LEFT_PAREN"
-	RIGHT_PAREN          = "RIGHT_PAREN"
-	LEFT_BRACE           = "LEFT_BRACE"
-	RIGHT_BRACE          = "RIGHT_BRACE"
-	COMMA                = "COMMA"
-	DOT                  = "DOT"
-	MINUS                = "MINUS"
-	PLUS                 = "PLUS"
-	SEMICOLON            = "SEMICOLON"
-	SLASH                = "SLASH"
-	STAR                 = "STAR"
-	BANG                 = "BANG"
-	BANG_EQUAL           = "BANG_EQUAL"
-	EQUAL                = "EQUAL"
-	EQUAL_EQUAL          = "EQUAL_EQUAL"
-	GREATER              = "GREATER"
-	GREATER_EQUAL        = "GREATER_EQUAL"
-	LESS                 = "LESS"
-	LESS_EQUAL           = "LESS_EQUAL"
-	IDENTIFIER           = "IDENTIFIER"
-	STRING               = "STRING"
-	NUMBER               = "NUMBER"
-	AND                  = "AND"
-	CLASS                = "CLASS"
-	ELSE                 = "ELSE"
-	FALSE                = "FALSE"
-	FUN                  = "FUN"
-	FOR                  = "FOR"
-	IF                   = "IF"
-	NIL                  = "NIL"
-	OR                   = "OR"
-	PRINT                = "PRINT"
-	RETURN               = "RETURN"
-	SUPER                = "SUPER"
-	THIS                 = "THIS"
-	TRUE                 = "TRUE"
-	VAR                  = "VAR"
-	WHILE                = "WHILE"
-	PERCENT              = "PERCENT"
-	EOF                  = "EOF"
+	LEFT_PAREN        Lexeme = "LEFT_PAREN"
+	RIGHT_PAREN              = "RIGHT_PAREN"
+	LEFT_BRACE               = "LEFT_BRACE"
+	RIGHT_BRACE              = "RIGHT_BRACE"
+	COMMA                    = "COMMA"
+	DOT                      = "DOT"
+	MINUS                    = "MINUS"
+	PLUS                     = "PLUS"
+	SEMICOLON                = "SEMICOLON"
+	SLASH                    = "SLASH"
+	STAR                     = "STAR"
+	BANG                     = "BANG"
+	BANG_EQUAL               = "BANG_EQUAL"
+	EQUAL                    = "EQUAL"
+	EQUAL_EQUAL              = "EQUAL_EQUAL"
+	GREATER                  = "GREATER"
+	GREATER_EQUAL            = "GREATER_EQUAL"
+	LESS                     = "LESS"
+	LESS_EQUAL               = "LESS_EQUAL"
+	IDENTIFIER               = "IDENTIFIER"
+	STRING                   = "STRING"
+	INTERPOLATION            = "INTERPOLATION"
+	DOC_COMMENT              = "DOC_COMMENT"
+	NUMBER                   = "NUMBER"
+	AND                      = "AND"
+	CLASS                    = "CLASS"
+	ELSE                     = "ELSE"
+	FALSE                    = "FALSE"
+	FUN                      = "FUN"
+	FOR                      = "FOR"
+	IF                       = "IF"
+	NIL                      = "NIL"
+	OR                       = "OR"
+	PRINT                    = "PRINT"
+	RETURN                   = "RETURN"
+	SUPER                    = "SUPER"
+	THIS                     = "THIS"
+	TRUE                     = "TRUE"
+	VAR                      = "VAR"
+	WHILE                    = "WHILE"
+	PERCENT                  = "PERCENT"
+	AMPERSAND                = "AMPERSAND"
+	PIPE                     = "PIPE"
+	CARET                    = "CARET"
+	TILDE                    = "TILDE"
+	LESS_LESS                = "LESS_LESS"
+	GREATER_GREATER          = "GREATER_GREATER"
+	PLUS_EQUAL               = "PLUS_EQUAL"
+	MINUS_EQUAL              = "MINUS_EQUAL"
+	STAR_EQUAL               = "STAR_EQUAL"
+	SLASH_EQUAL              = "SLASH_EQUAL"
+	PERCENT_EQUAL            = "PERCENT_EQUAL"
+	PLUS_PLUS                = "PLUS_PLUS"
+	MINUS_MINUS              = "MINUS_MINUS"
+	QUESTION                 = "QUESTION"
+	QUESTION_QUESTION        = "QUESTION_QUESTION"
+	COLON                    = "COLON"
+	EOF                      = "EOF"
 )
 
 type Token struct {
@@ -52,6 +70,7 @@ type Token struct {
 	lexeme    string
 	literal   interface{}
 	line      int
+	column    int
 }
 
 func (t Token) String() string {